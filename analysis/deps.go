@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// ANY_INDEX is the key of segments accessed with a numeric index.
+const ANY_INDEX = "*"
+
+// Segment is one step of a Path.
+type Segment struct {
+	Key     string
+	Index   bool // array element access, a[0]
+	Dynamic bool // key computed by an inner expression, a.{{b}}
+	Span    parser.Span
+}
+
+// Path is an input path read by a template, e.g. user.name or items[*].id.
+type Path struct {
+	Segments []Segment
+	Dynamic  bool
+	Guarded  bool // read as the primary of a ?? and so has a fallback
+	Span     parser.Span
+}
+
+// String returns p as written in a template, with keys quoted where they
+// cannot be written bare, e.g. user."first name".
+func (p Path) String() string {
+	var out strings.Builder
+	for i, s := range p.Segments {
+		key := s.Key
+		if text, ok := parser.KeyText(key); ok && !s.Dynamic && key != ANY_INDEX {
+			key = text
+		}
+		switch {
+		case s.Index:
+			out.WriteByte('[')
+			out.WriteString(key)
+			out.WriteByte(']')
+		case i > 0:
+			out.WriteByte('.')
+			out.WriteString(key)
+		default:
+			out.WriteString(key)
+		}
+	}
+	return out.String()
+}
+
+// id identifies the segments of p, which String may not for keys that cannot
+// be written.
+func (p Path) id() string {
+	var out strings.Builder
+	for _, s := range p.Segments {
+		fmt.Fprintf(&out, "%t %t %q;", s.Index, s.Dynamic, s.Key)
+	}
+	return out.String()
+}
+
+// Dependencies returns the set of input paths read by e in order of first
+// appearance. Keys computed by inner wildcards make a path dynamic, and the
+// paths read by those wildcards are reported as well.
+func Dependencies(e parser.Expression) []Path {
+	seen := map[string]bool{}
+	var out []Path
+	for _, p := range References(e) {
+		id := p.id()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, p)
+	}
+	return out
+}
+
+// References returns every read of an input path in e, including repeated
+// reads of the same path.
+func References(e parser.Expression) []Path {
+	d := &deps{}
+	d.value(e, false)
+	return d.refs
+}
+
+type deps struct {
+	refs []Path
+}
+
+func (d *deps) add(p Path) {
+	d.refs = append(d.refs, p)
+}
+
+// value collects the dependencies of e evaluated as a value.
+func (d *deps) value(e parser.Expression, guarded bool) {
+	switch v := e.(type) {
+	case *parser.Literal:
+		if !v.Quoted {
+			d.add(root(v, guarded))
+		}
+	case *parser.Wildcard:
+		d.value(v.Expression, guarded)
+	case *parser.DotExpression, *parser.IndexExpression:
+		for _, p := range d.resolve(e, guarded) {
+			d.add(p)
+		}
+	case *parser.NullCoalesceExpression:
+		d.value(v.Primary, true)
+		d.value(v.Fallback, guarded)
+	case *parser.FunctionExpression:
+		d.value(v.Argument, false)
+		d.name(v.Name)
+	}
+}
+
+func root(l *parser.Literal, guarded bool) Path {
+	return Path{
+		Segments: []Segment{{Key: l.V, Span: l.Span}},
+		Guarded:  guarded,
+		Span:     l.Span,
+	}
+}
+
+// key collects the dependencies of e used as a key. Literals are names rather
+// than lookups, other keys are evaluated for their value.
+func (d *deps) key(e parser.Expression) {
+	if _, ok := e.(*parser.Literal); ok || e == nil {
+		return
+	}
+	d.value(e, false)
+}
+
+// name collects the dependencies of the wildcards in e used as a function
+// name, which is not evaluated otherwise.
+func (d *deps) name(e parser.Expression) {
+	if e == nil {
+		return
+	}
+	if w, ok := e.(*parser.Wildcard); ok {
+		d.value(w, false)
+		return
+	}
+	for _, child := range parser.Children(e) {
+		d.name(child)
+	}
+}
+
+// resolve returns the paths an access chain can resolve to. Chains rooted at a
+// coalesce resolve to a path for each operand; chains rooted at constants,
+// function results or wildcards resolve to none.
+func (d *deps) resolve(e parser.Expression, guarded bool) []Path {
+	var target, key parser.Expression
+	var index bool
+	switch v := e.(type) {
+	case *parser.Literal:
+		if v.Quoted {
+			return nil
+		}
+		return []Path{root(v, guarded)}
+	case *parser.NullCoalesceExpression:
+		return append(d.resolve(v.Primary, true), d.resolve(v.Fallback, guarded)...)
+	case *parser.DotExpression:
+		target, key = v.Target, v.Key
+	case *parser.IndexExpression:
+		target, key, index = v.Target, v.Key, true
+	default:
+		d.value(e, guarded)
+		return nil
+	}
+
+	d.key(key)
+	seg := Segment{Key: key.Literal(), Span: key.Pos()}
+	if l, ok := key.(*parser.Literal); ok {
+		seg.Key = l.V
+		if index && IsNumber(l.V) {
+			seg.Key = ANY_INDEX
+			seg.Index = true
+		}
+	} else {
+		seg.Index = index
+		seg.Dynamic = true
+	}
+
+	targets := d.resolve(target, guarded)
+	out := make([]Path, 0, len(targets))
+	for _, t := range targets {
+		segments := make([]Segment, len(t.Segments), len(t.Segments)+1)
+		copy(segments, t.Segments)
+		out = append(out, Path{
+			Segments: append(segments, seg),
+			Dynamic:  t.Dynamic || seg.Dynamic,
+			Guarded:  t.Guarded,
+			Span:     parser.Span{Start: t.Span.Start, End: e.Pos().End},
+		})
+	}
+	return out
+}
+
+// IsNumber reports whether s is a non-negative integer, which reads an array
+// element when used as a key.
+func IsNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestDependencies(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected []string
+		dynamic  []bool
+	}{
+		{"{{user.name}}", []string{"user.name"}, []bool{false}},
+		{`{{"user.name"}}`, nil, nil},
+		{"{{items[0].id}}", []string{"items[*].id"}, []bool{false}},
+		{`{{user["first name"]}}`, []string{`user."first name"`}, []bool{false}},
+		{`{{user["a.b"] ?? user.a.b}}`, []string{`user."a.b"`, "user.a.b"}, []bool{false, false}},
+		{`{{a[(b ?? c)]}}`, []string{"b", "c", "a[(b ?? c)]"}, []bool{false, false, true}},
+		{`{{a.(b | toUpper)}}`, []string{"b", "a.(b | toUpper)"}, []bool{false, true}},
+		{"{{a.{{b}}}}", []string{"b", "a.{{b}}"}, []bool{false, true}},
+		{"{{a[{{b.c}}].d}}", []string{"b.c", "a[{{b.c}}].d"}, []bool{false, true}},
+		{"{{a.b ?? a.c ?? a.b}}", []string{"a.b", "a.c"}, []bool{false, false}},
+		{"{{(a ?? b).c}}", []string{"a.c", "b.c"}, []bool{false, false}},
+		{`{{ user.name | toUpper ?? "x" }}`, []string{"user.name"}, []bool{false}},
+		{"{{ {{a}}.b }}", []string{"a"}, []bool{false}},
+		{"{{ (a | f).b }}", []string{"a"}, []bool{false}},
+	}
+
+	for i, test := range tt {
+		t.Logf("deps-%d %s", i, test.input)
+		ast, err := parser.New(tokenizer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := Dependencies(ast.Root)
+		if len(paths) != len(test.expected) {
+			t.Fatalf("wrong number of paths, expected=%v got=%v", test.expected, paths)
+		}
+		for j, p := range paths {
+			if p.String() != test.expected[j] {
+				t.Errorf("wrong path, expected=%s got=%s", test.expected[j], p.String())
+			}
+			if p.Dynamic != test.dynamic[j] {
+				t.Errorf("wrong dynamic flag for %s, expected=%t got=%t", p, test.dynamic[j], p.Dynamic)
+			}
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected []string
+		guarded  []bool
+	}{
+		{"{{a.b ?? a.b}}", []string{"a.b", "a.b"}, []bool{true, false}},
+		{"{{a ?? b ?? c}}", []string{"a", "b", "c"}, []bool{true, true, false}},
+		{"{{(a.b | f) ?? c}}", []string{"a.b", "c"}, []bool{false, false}},
+		{"{{a.{{b}} ?? c}}", []string{"b", "a.{{b}}", "c"}, []bool{false, true, false}},
+	}
+
+	for i, test := range tt {
+		t.Logf("refs-%d %s", i, test.input)
+		ast, err := parser.New(tokenizer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs := References(ast.Root)
+		if len(refs) != len(test.expected) {
+			t.Fatalf("wrong number of references, expected=%v got=%v", test.expected, refs)
+		}
+		for j, p := range refs {
+			if p.String() != test.expected[j] {
+				t.Errorf("wrong path, expected=%s got=%s", test.expected[j], p.String())
+			}
+			if p.Guarded != test.guarded[j] {
+				t.Errorf("wrong guarded flag for %s, expected=%t got=%t", p, test.guarded[j], p.Guarded)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package batch
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/lint"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Item is a template to check, named by where it came from. Err is the error
+// reading the template, if any.
+type Item struct {
+	Name   string
+	Source string
+	Err    error
+}
+
+// Result is the outcome of checking one Item. Err is the syntax error of the
+// template, if any.
+type Result struct {
+	Item        Item
+	Template    parser.Template
+	Diagnostics []diag.Diagnostic
+	Err         error
+}
+
+// HasErrors reports whether the template failed to parse or has error
+// diagnostics.
+func (r Result) HasErrors() bool {
+	if r.Err != nil {
+		return true
+	}
+	for _, d := range r.Diagnostics {
+		if d.Severity == diag.ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+type Options struct {
+	// Workers is the number of templates checked at once, GOMAXPROCS if zero.
+	Workers int
+	// Linter lints every template that parses, if set.
+	Linter *lint.Linter
+}
+
+// Run parses and lints items with a bounded pool of workers. Results are in
+// the order of items. If ctx is canceled Run stops starting new items and
+// returns every result together with ctx.Err(); items that were not started
+// have ctx.Err() as their Err.
+func Run(ctx context.Context, items []Item, opts Options) ([]Result, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	results := make([]Result, len(items))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = check(items[i], opts.Linter)
+			}
+		}()
+	}
+
+	sent := 0
+loop:
+	for ; sent < len(items); sent++ {
+		// select picks a ready case at random, so a canceled ctx is checked
+		// before offering every item
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case next <- sent:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	err := ctx.Err()
+	for i := sent; i < len(items); i++ {
+		results[i] = Result{Item: items[i], Err: err}
+	}
+	return results, err
+}
+
+func check(item Item, l *lint.Linter) Result {
+	r := Result{Item: item}
+	if item.Err != nil {
+		r.Err = item.Err
+		return r
+	}
+	r.Template, r.Err = parser.ParseTemplate(item.Source)
+	if r.Err == nil && l != nil {
+		r.Diagnostics = l.CheckTemplate(r.Template)
+	}
+	return r
+}
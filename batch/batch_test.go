@@ -0,0 +1,150 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/lint"
+)
+
+func TestRun(t *testing.T) {
+	items := make([]Item, 100)
+	for i := range items {
+		items[i] = Item{Name: fmt.Sprint(i), Source: fmt.Sprintf("{{ a.b%d }}", i)}
+	}
+	items[13].Source = "{{ a"
+	items[42].Source = "{{ a | toupper }}"
+
+	results, err := Run(context.Background(), items, Options{
+		Workers: 4,
+		Linter:  lint.New(nil, functions.Default),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Item != items[i] {
+			t.Fatalf("wrong order, expected=%s got=%s", items[i].Name, r.Item.Name)
+		}
+		switch i {
+		case 13:
+			if r.Err == nil || !r.HasErrors() {
+				t.Errorf("expected syntax error for %s", r.Item.Source)
+			}
+		case 42:
+			if len(r.Diagnostics) != 1 || r.Diagnostics[0].Code != lint.UNKNOWN_FUNCTION || !r.HasErrors() {
+				t.Errorf("expected unknown function, got=%v", r.Diagnostics)
+			}
+		default:
+			if r.HasErrors() || len(r.Template.Wildcards) == 0 {
+				t.Errorf("unexpected errors for %s: %v %v", r.Item.Source, r.Err, r.Diagnostics)
+			}
+		}
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	items := []Item{{Name: "a", Source: "{{a}}"}, {Name: "b", Source: "{{b}}"}}
+	results, err := Run(ctx, items, Options{Workers: 1})
+	if err != context.Canceled {
+		t.Errorf("wrong error, expected=%v got=%v", context.Canceled, err)
+	}
+	for i, r := range results {
+		if r.Item != items[i] || r.Err != context.Canceled || !r.HasErrors() {
+			t.Errorf("wrong result for a skipped item, got=%+v", r)
+		}
+	}
+}
+
+func TestFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.wc")
+	if err := os.WriteFile(path, []byte("\n {{ a }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.wc")
+	items := FromFiles([]string{missing, path})
+	if len(items) != 2 || items[0].Err == nil || items[1].Err != nil || items[1].Source != "\n {{ a }}\n" {
+		t.Fatalf("wrong items, got=%+v", items)
+	}
+	results, err := Run(context.Background(), items, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != items[0].Err || results[1].HasErrors() {
+		t.Errorf("expected only the unreadable file to fail, got=%+v", results)
+	}
+}
+
+func TestFromJSONL(t *testing.T) {
+	input := `"{{ a }}"
+
+{"name": "named", "template": "{{ b }}"}
+{"template": "{{ c }}"}
+`
+	items, err := FromJSONL(strings.NewReader(input), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Item{
+		{Name: "stdin:1", Source: "{{ a }}"},
+		{Name: "named", Source: "{{ b }}"},
+		{Name: "stdin:4", Source: "{{ c }}"},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("wrong items, expected=%v got=%v", expected, items)
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("wrong item, expected=%v got=%v", expected[i], items[i])
+		}
+	}
+
+	if _, err := FromJSONL(strings.NewReader("{{ a }}\n"), "stdin"); err == nil {
+		t.Error("expected error for a line that is not JSON")
+	}
+}
+
+func TestFromDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.wc":         "{{ b }}\n",
+		"a.wc":         "{{ a }}",
+		"nested/c.wc":  "{{ c }}",
+		"nested/c.txt": "ignored",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, err := FromDir(dir, "*.wc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Item{
+		{Name: filepath.Join(dir, "a.wc"), Source: "{{ a }}"},
+		{Name: filepath.Join(dir, "b.wc"), Source: "{{ b }}\n"},
+		{Name: filepath.Join(dir, "nested/c.wc"), Source: "{{ c }}"},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("wrong items, expected=%v got=%v", expected, items)
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("wrong item, expected=%v got=%v", expected[i], items[i])
+		}
+	}
+}
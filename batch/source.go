@@ -0,0 +1,79 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FromFiles reads one template from each file, byte for byte. Files that
+// cannot be read are kept as items with the error as Err.
+func FromFiles(paths []string) []Item {
+	items := make([]Item, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		items = append(items, Item{Name: path, Source: string(data), Err: err})
+	}
+	return items
+}
+
+// FromDir reads every file below root whose base name matches pattern, in
+// lexical order.
+func FromDir(root, pattern string) ([]Item, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ok, err := filepath.Match(pattern, d.Name())
+		if ok {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return FromFiles(paths), nil
+}
+
+// jsonlItem is a line of a JSONL stream given as an object.
+type jsonlItem struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+}
+
+// FromJSONL reads one template per line of r. A line is either a JSON string
+// or an object with the fields "template" and optionally "name". Items without
+// a name are named <name>:<line>.
+func FromJSONL(r io.Reader, name string) ([]Item, error) {
+	var items []Item
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			continue
+		}
+		item := Item{Name: fmt.Sprintf("%s:%d", name, line)}
+		if strings.HasPrefix(text, "{") {
+			var v jsonlItem
+			if err := json.Unmarshal([]byte(text), &v); err != nil {
+				return nil, fmt.Errorf("%s: %w", item.Name, err)
+			}
+			if v.Name != "" {
+				item.Name = v.Name
+			}
+			item.Source = v.Template
+		} else if err := json.Unmarshal([]byte(text), &item.Source); err != nil {
+			return nil, fmt.Errorf("%s: %w", item.Name, err)
+		}
+		items = append(items, item)
+	}
+	return items, s.Err()
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"unsafe"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Cache is a thread-safe LRU cache of parse results keyed by template source.
+// Cached ASTs are shared between callers and must not be modified.
+type Cache struct {
+	mu       sync.Mutex
+	size     int
+	maxBytes int64
+	bytes    int64
+	order    *list.List
+	items    map[string]*list.Element
+	stats    Stats
+}
+
+type entry struct {
+	src  string
+	ast  parser.AST
+	err  error
+	size int64
+}
+
+// Stats are the counters of a Cache.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+type Option func(*Cache)
+
+// WithMaxBytes evicts entries while the estimated memory of the cached
+// sources and trees exceeds n bytes.
+func WithMaxBytes(n int64) Option {
+	return func(c *Cache) {
+		c.maxBytes = n
+	}
+}
+
+// New returns a Cache holding at most size parse results.
+func New(size int, opts ...Option) *Cache {
+	c := &Cache{
+		size:  size,
+		order: list.New(),
+		items: map[string]*list.Element{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Parse returns the cached result of parsing src, parsing it on a miss.
+// Syntax errors are cached as well.
+func (c *Cache) Parse(src string) (parser.AST, error) {
+	c.mu.Lock()
+	if el, ok := c.items[src]; ok {
+		c.order.MoveToFront(el)
+		c.stats.Hits++
+		e := el.Value.(*entry)
+		c.mu.Unlock()
+		return e.ast, e.err
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	ast, err := parser.New(tokenizer.New(src)).Parse()
+	e := &entry{src: src, ast: ast, err: err, size: estimate(src, ast)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[src]; ok {
+		// another goroutine parsed src in the meantime
+		c.order.MoveToFront(el)
+		e := el.Value.(*entry)
+		return e.ast, e.err
+	}
+	if c.size <= 0 || (c.maxBytes > 0 && e.size > c.maxBytes) {
+		return ast, err
+	}
+	c.items[src] = c.order.PushFront(e)
+	c.bytes += e.size
+	for c.order.Len() > c.size || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.evict()
+	}
+	return ast, err
+}
+
+func (c *Cache) evict() {
+	el := c.order.Back()
+	e := el.Value.(*entry)
+	c.order.Remove(el)
+	delete(c.items, e.src)
+	c.bytes -= e.size
+	c.stats.Evictions++
+}
+
+// Stats returns a snapshot of the counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.order.Len()
+	s.Bytes = c.bytes
+	return s
+}
+
+// Purge removes every entry without resetting the counters.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.items)
+	c.bytes = 0
+}
+
+// nodeSize is the approximate size of an AST node with its interface header.
+const nodeSize = int64(unsafe.Sizeof(parser.DotExpression{})) + 16
+
+// estimate returns the approximate memory held by an entry.
+func estimate(src string, ast parser.AST) int64 {
+	size := int64(unsafe.Sizeof(entry{})) + int64(len(src))
+	if ast.Root == nil {
+		return size
+	}
+	parser.Inspect(ast.Root, func(e parser.Expression) bool {
+		size += nodeSize
+		if l, ok := e.(*parser.Literal); ok {
+			size += int64(len(l.V))
+		}
+		return true
+	})
+	return size
+}
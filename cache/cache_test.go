@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestCache(t *testing.T) {
+	c := New(2)
+
+	first, err := c.Parse("{{a}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Parse("{{a}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Root != second.Root {
+		t.Error("expected the cached AST to be shared")
+	}
+
+	if _, err := c.Parse("a"); err == nil {
+		t.Fatal("expected syntax error")
+	}
+	if _, err := c.Parse("a"); err == nil {
+		t.Fatal("expected cached syntax error")
+	}
+
+	// {{a}} is least recently used and evicted
+	if _, err := c.Parse("{{b}}"); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := c.Parse("{{a}}")
+	if third.Root == first.Root {
+		t.Error("expected {{a}} to be evicted")
+	}
+
+	expected := Stats{Hits: 2, Misses: 4, Evictions: 2, Entries: 2}
+	actual := c.Stats()
+	actual.Bytes = 0
+	if actual != expected {
+		t.Errorf("wrong stats, expected=%+v got=%+v", expected, actual)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	ast, _ := parser.New(tokenizer.New("{{a}}")).Parse()
+	size := estimate("{{a}}", ast)
+	c := New(100, WithMaxBytes(3*size))
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.Parse(fmt.Sprintf("{{%c}}", 'a'+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := c.Stats()
+	if s.Entries != 3 || s.Bytes != 3*size || s.Evictions != 7 {
+		t.Errorf("wrong stats, got=%+v", s)
+	}
+
+	if _, err := c.Parse("{{ a.b.c.d.e.f.g.h }}"); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Stats(); s.Bytes > 3*size {
+		t.Errorf("cache exceeds max bytes, got=%+v", s)
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	c := New(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				src := fmt.Sprintf("{{ a.b%d ?? c }}", (i+j)%12)
+				ast, err := c.Parse(src)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if ast.Root.Literal() != fmt.Sprintf("{{(a.b%d ?? c)}}", (i+j)%12) {
+					t.Errorf("wrong AST for %s, got=%s", src, ast.Root.Literal())
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	s := c.Stats()
+	if s.Hits+s.Misses != 16*500 || s.Entries > 8 {
+		t.Errorf("wrong stats, got=%+v", s)
+	}
+}
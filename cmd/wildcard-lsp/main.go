@@ -0,0 +1,48 @@
+// Command wildcard-lsp is a language server for templates, speaking the
+// Language Server Protocol over stdin and stdout.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jorgepbrown/wildcard-tree/lint"
+	"github.com/jorgepbrown/wildcard-tree/lsp"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+)
+
+func main() {
+	schemaFile := flag.String("schema", "", "JSON schema `file` of the data the templates read")
+	config := flag.String("config", "", "JSON lint configuration `file`")
+	flag.Parse()
+
+	var opts []lsp.Option
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err != nil {
+			fail(err)
+		}
+		s, err := schema.Parse(data)
+		if err != nil {
+			fail(err)
+		}
+		opts = append(opts, lsp.WithSchema(s))
+	}
+	if *config != "" {
+		cfg, err := lint.LoadConfig(*config)
+		if err != nil {
+			fail(err)
+		}
+		opts = append(opts, lsp.WithLintConfig(cfg))
+	}
+
+	if err := lsp.New(os.Stdin, os.Stdout, opts...).Serve(); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", lsp.NAME, err)
+	os.Exit(1)
+}
@@ -0,0 +1,599 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/batch"
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/extract"
+	"github.com/jorgepbrown/wildcard-tree/format"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/highlight"
+	"github.com/jorgepbrown/wildcard-tree/lint"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/render"
+	"github.com/jorgepbrown/wildcard-tree/repl"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+	"github.com/jorgepbrown/wildcard-tree/semdiff"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+	"github.com/jorgepbrown/wildcard-tree/typecheck"
+)
+
+func init() {
+	commands = []*command{
+		{"tokenize", "[file|glob ...]", "print the tokens of templates", runTokenize},
+		{"parse", "[file|glob ...]", "print the syntax trees of templates as JSON", runParse},
+		{"eval", "[file|glob ...]", "evaluate templates against JSON data", runEval},
+		{"fmt", "[file|glob ...]", "print templates in canonical form", runFmt},
+		{"highlight", "[file|glob ...]", "print templates with syntax highlighting", runHighlight},
+		{"lint", "[file|glob ...]", "report style problems and likely mistakes", runLint},
+		{"check", "[file|glob ...]", "type check templates against a JSON schema of their input", runCheck},
+		{"diff", "<old file> <new file>", "print the semantic changes between two templates", runDiff},
+		{"batch", "[file|dir ...]", "parse and lint many templates in parallel", runBatch},
+		{"extract", "<file ...>", "print the wildcards in JSON and YAML documents", runExtract},
+		{"repl", "", "start an interactive session", runRepl},
+	}
+}
+
+func runTokenize(args []string) int {
+	fs := newFlagSet("tokenize")
+	in := addInputFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for i, input := range inputs {
+		header(os.Stdout, inputs, i)
+		t := tokenizer.New(input.Source)
+		for tok := t.Next(); tok.T != tokenizer.EOF; tok = t.Next() {
+			fmt.Printf("%s %s\n", tok.T, tok.Literal)
+		}
+	}
+	return 0
+}
+
+// FORMATS lists the names of the syntax tree formats for flag usages.
+const FORMATS = "json, compact, tree, sexpr or dot"
+
+func runParse(args []string) int {
+	fs := newFlagSet("parse")
+	in := addInputFlags(fs)
+	formatName := fs.String("format", "json", "how syntax trees are printed, "+FORMATS)
+	color := addColorFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	format, ok := render.FormatOf(*formatName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %s\n", *formatName)
+		return 2
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	code := 0
+	for i, input := range inputs {
+		tmpl, err := parse(input, color)
+		if err != nil {
+			code = 1
+			continue
+		}
+		header(os.Stdout, inputs, i)
+		for _, w := range tmpl.Wildcards {
+			if err := render.AST(os.Stdout, parser.AST{Root: w}, format); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+		}
+	}
+	return code
+}
+
+func runEval(args []string) int {
+	fs := newFlagSet("eval")
+	in := addInputFlags(fs)
+	dataFile := fs.String("data", "", "JSON `file` the templates read from, - for stdin")
+	asJSON := fs.Bool("json", false, "print strings as JSON too")
+	color := addColorFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var data any
+	if *dataFile != "" {
+		if data, err = readJSON(*dataFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	code := 0
+	for i, input := range inputs {
+		tmpl, err := parse(input, color)
+		if err != nil {
+			code = 1
+			continue
+		}
+		root, err := single(tmpl)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input.Name, err)
+			code = 1
+			continue
+		}
+		v, err := eval.Evaluate(root, data, functions.Default)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input.Name, err)
+			code = 1
+			continue
+		}
+		header(os.Stdout, inputs, i)
+		if s, ok := v.(string); ok && !*asJSON {
+			fmt.Println(s)
+			continue
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input.Name, err)
+			code = 1
+			continue
+		}
+		fmt.Println(string(out))
+	}
+	return code
+}
+
+func runFmt(args []string) int {
+	fs := newFlagSet("fmt")
+	in := addInputFlags(fs)
+	write := fs.Bool("w", false, "write the result to the file instead of stdout")
+	list := fs.Bool("l", false, "list the files whose formatting differs")
+	color := addColorFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	for i, input := range inputs {
+		tmpl, err := parse(input, color)
+		if err != nil {
+			code = 1
+			continue
+		}
+		formatted, err := format.Template(tmpl)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", input.Name, err)
+			code = 1
+			continue
+		}
+		if *list && formatted != input.Source {
+			fmt.Println(input.Name)
+		}
+		if *write && input.File {
+			if formatted != input.Source {
+				if err := os.WriteFile(input.Name, []byte(formatted), 0o644); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return 2
+				}
+			}
+			continue
+		}
+		if !*list {
+			header(os.Stdout, inputs, i)
+			if color.enabled(os.Stdout) {
+				formatted = highlight.ANSI(formatted)
+			}
+			printText(formatted)
+		}
+	}
+	return code
+}
+
+func runHighlight(args []string) int {
+	fs := newFlagSet("highlight")
+	in := addInputFlags(fs)
+	asHTML := fs.Bool("html", false, "print HTML instead of ANSI colors")
+	css := fs.Bool("css", false, "print the stylesheet of the HTML output and exit")
+	color := addColorFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *css {
+		fmt.Print(highlight.CSS)
+		return 0
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for i, input := range inputs {
+		header(os.Stdout, inputs, i)
+		switch {
+		case *asHTML:
+			fmt.Printf("<pre class=\"wildcard\"><code>%s</code></pre>\n", highlight.HTML(input.Source))
+		case color.enabled(os.Stdout):
+			printText(highlight.ANSI(input.Source))
+		default:
+			printText(input.Source)
+		}
+	}
+	return 0
+}
+
+func runLint(args []string) int {
+	fs := newFlagSet("lint")
+	in := addInputFlags(fs)
+	config := fs.String("config", "", "JSON lint configuration `file`")
+	fix := fs.Bool("fix", false, "apply fixes to the files in place")
+	rep := addReporterFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	l, err := newLinter(*config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	for _, input := range inputs {
+		tmpl, err := rep.parse(input)
+		if err != nil {
+			code = 1
+			continue
+		}
+		diags := l.CheckTemplate(tmpl)
+		hasErrors, err := rep.report(input, diags)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if hasErrors {
+			code = 1
+		}
+		if *fix && input.File {
+			if fixed := lint.Fix(input.Source, diags); fixed != input.Source {
+				if err := os.WriteFile(input.Name, []byte(fixed), 0o644); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return 2
+				}
+			}
+		}
+	}
+	return code
+}
+
+func runCheck(args []string) int {
+	fs := newFlagSet("check")
+	in := addInputFlags(fs)
+	schemaFile := fs.String("schema", "", "JSON schema `file` of the data the templates read")
+	rep := addReporterFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	var s *schema.Schema
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err == nil {
+			s, err = schema.Parse(data)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	inputs, err := in.read(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	for _, input := range inputs {
+		tmpl, err := rep.parse(input)
+		if err != nil {
+			code = 1
+			continue
+		}
+		var diags []diag.Diagnostic
+		for _, w := range tmpl.Wildcards {
+			diags = append(diags, typecheck.Check(w, s, functions.Default).Errors...)
+			if s != nil {
+				diags = append(diags, schema.Check(w, s)...)
+			}
+		}
+		sort.SliceStable(diags, func(i, j int) bool {
+			return diags[i].Span.Start < diags[j].Span.Start
+		})
+		hasErrors, err := rep.report(input, diags)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if hasErrors {
+			code = 1
+		}
+	}
+	return code
+}
+
+// runDiff exits like diff(1): 0 without changes, 1 with changes and 2 on
+// errors.
+func runDiff(args []string) int {
+	fs := newFlagSet("diff")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	items := batch.FromFiles(fs.Args())
+	for _, item := range items {
+		if item.Err != nil {
+			fmt.Fprintln(os.Stderr, item.Err)
+			return 2
+		}
+	}
+	// the files hold a single wildcard each, compared regardless of whitespace
+	changes, err := semdiff.Compare(strings.TrimSpace(items[0].Source), strings.TrimSpace(items[1].Source))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runBatch(args []string) int {
+	fs := newFlagSet("batch")
+	config := fs.String("config", "", "JSON lint configuration `file`")
+	workers := fs.Int("workers", 0, "templates checked at once, the number of CPUs if 0")
+	pattern := fs.String("pattern", "*.wc", "`glob` of the files read from directories")
+	jsonl := fs.String("jsonl", "", "JSONL `file` of templates, - for stdin")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	l, err := newLinter(*config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var items []batch.Item
+	for _, path := range fs.Args() {
+		var found []batch.Item
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			found, err = batch.FromDir(path, *pattern)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+		} else {
+			found = batch.FromFiles([]string{path})
+		}
+		items = append(items, found...)
+	}
+	if *jsonl != "" {
+		r, name := os.Stdin, STDIN
+		if *jsonl != "-" {
+			f, err := os.Open(*jsonl)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+			defer f.Close()
+			r, name = f, *jsonl
+		}
+		found, err := batch.FromJSONL(r, name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		items = append(items, found...)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := batch.Run(ctx, items, batch.Options{Workers: *workers, Linter: l})
+
+	code := 0
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("%s: %s\n", r.Item.Name, r.Err)
+		}
+		for _, d := range r.Diagnostics {
+			fmt.Printf("%s:%s\n", r.Item.Name, d)
+		}
+		if r.HasErrors() {
+			code = 1
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return code
+}
+
+func runExtract(args []string) int {
+	fs := newFlagSet("extract")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	code := 0
+	for _, file := range fs.Args() {
+		results, err := extract.File(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		for _, r := range results {
+			for _, err := range r.Errs {
+				fmt.Printf("%s:%s: %s: %s\n", file, r.ErrorPosition(err), r.Path, err)
+				code = 1
+			}
+			for _, w := range r.Template.Wildcards {
+				fmt.Printf("%s:%s: %s: %s\n", file, r.Position(w.Start), r.Path, r.Value[w.Start:w.End])
+			}
+		}
+	}
+	return code
+}
+
+func runRepl(args []string) int {
+	fs := newFlagSet("repl")
+	mode := fs.String("mode", "parse", "what to print for every template, tokenize, parse or eval")
+	formatName := fs.String("format", "json", "how syntax trees are printed, "+FORMATS)
+	color := addColorFlag(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	step, ok := repl.ParseStepOf(*mode)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown mode %s\n", *mode)
+		return 2
+	}
+	format, ok := render.FormatOf(*formatName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %s\n", *formatName)
+		return 2
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	r := repl.New(os.Stdin, os.Stdout, os.Stderr,
+		repl.WithMode(step),
+		repl.WithFormat(format),
+		repl.WithHistory(repl.DefaultHistoryPath()),
+		repl.WithInterrupts(interrupts),
+		repl.WithColor(color.enabled(os.Stdout)),
+	)
+	if err := r.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// parse parses input as a template, printing the syntax error to stderr,
+// colored as selected by color.
+func parse(in input, color *colorFlag) (parser.Template, error) {
+	tmpl, err := parser.ParseTemplate(in.Source)
+	if err != nil {
+		d, ok := diag.FromError(err)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", in.Name, err)
+			return tmpl, err
+		}
+		format := diag.TEXT
+		if color.enabled(os.Stderr) {
+			format = diag.ANSI
+		}
+		if rerr := diag.Render(os.Stderr, in.Name, in.Source, []diag.Diagnostic{d}, format); rerr != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", in.Name, err)
+		}
+	}
+	return tmpl, err
+}
+
+// parse parses input as a template, reporting the syntax error as a
+// diagnostic.
+func (r *reporter) parse(in input) (parser.Template, error) {
+	tmpl, err := parser.ParseTemplate(in.Source)
+	if err != nil {
+		d, ok := diag.FromError(err)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", in.Name, err)
+			return tmpl, err
+		}
+		if _, rerr := r.report(in, []diag.Diagnostic{d}); rerr != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", in.Name, err)
+		}
+	}
+	return tmpl, err
+}
+
+// single returns the only wildcard of tmpl, which may be surrounded by
+// whitespace but no other text.
+func single(tmpl parser.Template) (*parser.Wildcard, error) {
+	if len(tmpl.Wildcards) != 1 {
+		return nil, fmt.Errorf("expected a single wildcard, found %d", len(tmpl.Wildcards))
+	}
+	span := tmpl.Wildcards[0].Pos()
+	if strings.TrimSpace(tmpl.Source[:span.Start]+tmpl.Source[span.End:]) != "" {
+		return nil, fmt.Errorf("expected a single wildcard, found text around it")
+	}
+	return tmpl.Wildcards[0], nil
+}
+
+// printText prints s, ending it with a newline unless it has one.
+func printText(s string) {
+	if strings.HasSuffix(s, "\n") {
+		fmt.Print(s)
+		return
+	}
+	fmt.Println(s)
+}
+
+func newLinter(config string) (*lint.Linter, error) {
+	var cfg *lint.Config
+	if config != "" {
+		var err error
+		cfg, err = lint.LoadConfig(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lint.New(cfg, functions.Default), nil
+}
+
+func readJSON(path string) (any, error) {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+	var v any
+	if err := json.NewDecoder(f).Decode(&v); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return v, nil
+}
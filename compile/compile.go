@@ -0,0 +1,176 @@
+package compile
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/optimize"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Program is a compiled template. It is immutable and safe for concurrent use.
+type Program struct {
+	run node
+}
+
+// node evaluates one compiled expression.
+type node func(ctx context.Context, data any) (any, error)
+
+type config struct {
+	functions *functions.Registry
+}
+
+type Option func(*config)
+
+// WithFunctions resolves the functions of the template with r instead of
+// functions.Default. If r is nil every function is unknown.
+func WithFunctions(r *functions.Registry) Option {
+	return func(c *config) {
+		c.functions = r
+	}
+}
+
+// Compile optimizes ast and turns it into a Program. Functions and literal
+// keys are resolved once here instead of on every run, so unknown functions
+// are reported as errors.
+func Compile(ast parser.AST, opts ...Option) (*Program, error) {
+	c := &config{functions: functions.Default}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if ast.Root == nil {
+		return nil, newEmptyASTError()
+	}
+	run, err := c.value(optimize.Optimize(ast.Root, c.functions))
+	if err != nil {
+		return nil, err
+	}
+	return &Program{run: run}, nil
+}
+
+// Run evaluates the program against the JSON decoded data with the semantics
+// of eval.Evaluate.
+func (p *Program) Run(ctx context.Context, data any) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.run(ctx, data)
+}
+
+func (c *config) value(e parser.Expression) (node, error) {
+	switch v := e.(type) {
+	case *parser.Literal:
+		if v.Quoted {
+			s := v.V
+			return func(context.Context, any) (any, error) {
+				return s, nil
+			}, nil
+		}
+		access := accessor(v.V)
+		return func(_ context.Context, data any) (any, error) {
+			return access(data), nil
+		}, nil
+	case *parser.Wildcard:
+		return c.value(v.Expression)
+	case *parser.DotExpression:
+		return c.access(v.Target, v.Key)
+	case *parser.IndexExpression:
+		return c.access(v.Target, v.Key)
+	case *parser.NullCoalesceExpression:
+		primary, err := c.value(v.Primary)
+		if err != nil {
+			return nil, err
+		}
+		fallback, err := c.value(v.Fallback)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, data any) (any, error) {
+			p, err := primary(ctx, data)
+			if err != nil || p != nil {
+				return p, err
+			}
+			return fallback(ctx, data)
+		}, nil
+	case *parser.FunctionExpression:
+		arg, err := c.value(v.Argument)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := v.Name.(*parser.Literal)
+		if !ok {
+			return nil, newInvalidFunctionNameError(v.Name)
+		}
+		if c.functions == nil {
+			return nil, newUnknownFunctionError(name.V, nil)
+		}
+		f, ok := c.functions.Lookup(name.V)
+		if !ok {
+			return nil, newUnknownFunctionError(name.V, c.functions.Suggest(name.V))
+		}
+		return func(ctx context.Context, data any) (any, error) {
+			a, err := arg(ctx, data)
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return f.Apply(a)
+		}, nil
+	}
+	return nil, newUnknownExprTypeError(e.Type())
+}
+
+func (c *config) access(target, key parser.Expression) (node, error) {
+	t, err := c.value(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if l, ok := key.(*parser.Literal); ok {
+		access := accessor(l.V)
+		return func(ctx context.Context, data any) (any, error) {
+			v, err := t(ctx, data)
+			if err != nil {
+				return nil, err
+			}
+			return access(v), nil
+		}, nil
+	}
+
+	k, err := c.value(key)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, data any) (any, error) {
+		v, err := t(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		kv, err := k(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		return eval.Access(v, eval.ToKey(kv)), nil
+	}, nil
+}
+
+// accessor returns eval.Access for a key known at compile time.
+func accessor(key string) func(v any) any {
+	index, err := strconv.Atoi(key)
+	isIndex := err == nil && index >= 0
+	return func(v any) any {
+		switch t := v.(type) {
+		case map[string]any:
+			return t[key]
+		case []any:
+			if isIndex && index < len(t) {
+				return t[index]
+			}
+		}
+		return nil
+	}
+}
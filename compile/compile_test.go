@@ -0,0 +1,166 @@
+package compile
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+const testData = `{
+	"user": {"name": "ada", "nickname": null, "tags": ["a", "b"]},
+	"key": "name",
+	"index": 1,
+	"items": [{"id": 1}, {"id": 2}]
+}`
+
+var testInputs = []string{
+	`{{"user"}}`,
+	"{{user.name}}",
+	"{{user.missing}}",
+	"{{missing.deeply.nested}}",
+	`{{user["name"]}}`,
+	"{{user.{{key}}}}",
+	"{{user.tags[1]}}",
+	"{{user.tags[{{index}}]}}",
+	"{{user.tags[5]}}",
+	"{{items.0.id}}",
+	`{{user.nickname ?? "anonymous"}}`,
+	"{{user.nickname ?? user.name | toUpper}}",
+	"{{user.tags | length}}",
+	`{{ ("x" | toUpper) ?? user.name }}`,
+	"{{user.tags | toUpper}}",
+}
+
+func TestRunMatchesEvaluate(t *testing.T) {
+	data := testDecode(t)
+	for i, input := range testInputs {
+		t.Logf("compile-%d %s", i, input)
+		ast := testParse(t, input)
+		p, err := Compile(ast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, expectedErr := eval.Evaluate(ast.Root, data, functions.Default)
+		actual, err := p.Run(context.Background(), data)
+		if (expectedErr == nil) != (err == nil) {
+			t.Fatalf("wrong error, expected=%v got=%v", expectedErr, err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("wrong result, expected=%v got=%v", expected, actual)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tt := []struct {
+		input string
+		err   string
+	}{
+		{"{{ a | toupper }}", "unknown function toupper, did you mean toUpper?"},
+		{`{{ a | toUpper ?? "x" }}`, `function name must be a literal, got (toUpper ?? "x")`},
+	}
+
+	for _, test := range tt {
+		_, err := Compile(testParse(t, test.input))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("wrong error, expected=%s got=%v", test.err, err)
+		}
+	}
+	if _, err := Compile(testParse(t, "{{ a | toUpper }}"), WithFunctions(nil)); err == nil || err.Error() != "unknown function toUpper" {
+		t.Errorf("wrong error, expected=unknown function toUpper got=%v", err)
+	}
+	if _, err := Compile(parser.AST{}); err == nil || err.Error() != EMPTY_AST {
+		t.Errorf("wrong error, expected=%s got=%v", EMPTY_AST, err)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	p, err := Compile(testParse(t, "{{ a }}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Run(ctx, nil); err != context.Canceled {
+		t.Errorf("wrong error, expected=%v got=%v", context.Canceled, err)
+	}
+}
+
+func TestRunConcurrent(t *testing.T) {
+	data := testDecode(t)
+	p, err := Compile(testParse(t, "{{ user.nickname ?? user.{{key}} | toUpper }}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				v, err := p.Run(context.Background(), data)
+				if err != nil || v != "ADA" {
+					t.Errorf("wrong result, expected=ADA got=%v %v", v, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+const benchmarkInput = `{{ user.nickname ?? user.{{key}} ?? items[1].id }}`
+
+func BenchmarkEvaluate(b *testing.B) {
+	data := testDecode(b)
+	ast := testParse(b, benchmarkInput)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := eval.Evaluate(ast.Root, data, functions.Default); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	data := testDecode(b)
+	p, err := Compile(testParse(b, benchmarkInput))
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Run(ctx, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func testDecode(t testing.TB) any {
+	t.Helper()
+	var data any
+	if err := json.Unmarshal([]byte(testData), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testParse(t testing.TB, input string) parser.AST {
+	t.Helper()
+	ast, err := parser.New(tokenizer.New(input)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ast
+}
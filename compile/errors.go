@@ -0,0 +1,32 @@
+package compile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+)
+
+const (
+	EMPTY_AST             = "cannot compile an empty AST"
+	INVALID_FUNCTION_NAME = "function name must be a literal, got %s"
+	UNKNOWN_FUNCTION      = "unknown function %s"
+	UNKNOWN_EXPR_TYPE     = "cannot compile expression type %s"
+)
+
+func newEmptyASTError() error {
+	return errors.New(EMPTY_AST)
+}
+
+func newInvalidFunctionNameError(e parser.Expression) error {
+	return fmt.Errorf(INVALID_FUNCTION_NAME, e.Literal())
+}
+
+func newUnknownFunctionError(name string, suggestions []string) error {
+	return fmt.Errorf(UNKNOWN_FUNCTION+"%s", name, suggest.DidYouMean(suggestions, nil))
+}
+
+func newUnknownExprTypeError(t parser.ExpressionType) error {
+	return fmt.Errorf(UNKNOWN_EXPR_TYPE, t)
+}
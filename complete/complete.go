@@ -0,0 +1,357 @@
+package complete
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+	"github.com/jorgepbrown/wildcard-tree/types"
+)
+
+// Context is the syntactic position of the cursor in a wildcard.
+type Context string
+
+const (
+	NONE     Context = "none"
+	VALUE    Context = "value"    // after {{ or (
+	FIELD    Context = "field"    // after .
+	INDEX    Context = "index"    // after [
+	FUNCTION Context = "function" // after |
+	FALLBACK Context = "fallback" // after ??
+)
+
+type Kind string
+
+const (
+	FIELD_KIND    Kind = "field"
+	FUNCTION_KIND Kind = "function"
+)
+
+// Candidate is a completion at the cursor. Text replaces the source at Span,
+// the part of the name typed so far, and is Label quoted where the name
+// cannot be written bare.
+type Candidate struct {
+	Label  string
+	Text   string
+	Kind   Kind
+	Detail string // the type of a field or the signature of a function
+	Doc    string
+	Span   parser.Span
+}
+
+// Complete returns the candidates at the byte offset of the template src,
+// best first. Fields come from the schema s of the input and functions from
+// fns; either may be nil. src is only tokenized up to offset, so it may be
+// incomplete, e.g. `{{ user.`.
+func Complete(src string, offset int, s *schema.Schema, fns *functions.Registry) []Candidate {
+	tokens, word, ok := scan(src, offset)
+	if !ok {
+		return nil
+	}
+	c := &completer{
+		schema: s,
+		fns:    fns,
+		prefix: src[word.Start:word.End],
+		span:   word,
+	}
+	before := tokens[:len(tokens)-1]
+	switch contextOf(tokens) {
+	case VALUE:
+		c.fields(s, true, types.ANY)
+	case FALLBACK:
+		c.fields(s, true, c.typeOf(before).Without(types.NULL))
+	case FIELD, INDEX:
+		if target, ok := c.resolve(before); ok && !isArray(target) {
+			c.fields(target, false, types.ANY)
+		}
+	case FUNCTION:
+		c.functions(c.typeOf(before))
+	}
+	return c.ranked()
+}
+
+// ContextOf returns the context of the cursor at the byte offset of src.
+func ContextOf(src string, offset int) Context {
+	tokens, _, ok := scan(src, offset)
+	if !ok {
+		return NONE
+	}
+	return contextOf(tokens)
+}
+
+// scan returns the tokens before the cursor at offset and the span of the
+// word being typed, which is not part of the tokens. It reports false if the
+// cursor is not in a wildcard or is in a string.
+func scan(src string, offset int) ([]tokenizer.Token, parser.Span, bool) {
+	offset = max(0, min(offset, len(src)))
+	word := parser.Span{Start: offset, End: offset}
+	tokens := tokensAt(src, offset)
+	if len(tokens) == 0 {
+		return nil, word, false
+	}
+	switch last := tokens[len(tokens)-1]; {
+	case last.Quoted && !closed(last):
+		return nil, word, false
+	case last.T == tokenizer.TEXT && !last.Quoted && last.End == offset:
+		word.Start = last.Start
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens, word, true
+}
+
+func contextOf(tokens []tokenizer.Token) Context {
+	if len(tokens) == 0 {
+		return NONE
+	}
+	switch tokens[len(tokens)-1].T {
+	case tokenizer.WILDCARD_OPEN, tokenizer.LPAREN:
+		return VALUE
+	case tokenizer.DOT:
+		return FIELD
+	case tokenizer.LBRACKET:
+		return INDEX
+	case tokenizer.PIPE:
+		return FUNCTION
+	case tokenizer.NULL_COALESCE:
+		return FALLBACK
+	}
+	return NONE
+}
+
+// tokensAt returns the tokens of the wildcard open at offset, from its {{ up
+// to offset, or nil if offset is in the text between wildcards.
+func tokensAt(src string, offset int) []tokenizer.Token {
+	t := tokenizer.New("")
+	for pos := 0; ; {
+		i := strings.Index(src[pos:offset], "{{")
+		if i < 0 {
+			return nil
+		}
+		t.ResetAt(src[:offset], pos+i)
+		var tokens []tokenizer.Token
+		depth := 0
+		for tok := t.Next(); tok.T != tokenizer.EOF; tok = t.Next() {
+			tokens = append(tokens, tok)
+			switch tok.T {
+			case tokenizer.WILDCARD_OPEN:
+				depth++
+			case tokenizer.WILDCARD_CLOSE:
+				depth--
+			}
+			if depth == 0 {
+				pos = tok.End
+				break
+			}
+		}
+		if depth > 0 {
+			return tokens
+		}
+	}
+}
+
+// closed reports whether the quoted token tok ends with its closing quote.
+func closed(tok tokenizer.Token) bool {
+	return tok.End-tok.Start >= 2 && len(tok.Literal) == tok.End-tok.Start-2
+}
+
+type completer struct {
+	schema     *schema.Schema
+	fns        *functions.Registry
+	prefix     string
+	span       parser.Span
+	candidates []candidate
+}
+
+type candidate struct {
+	Candidate
+	match int // how well the label matches the prefix, lower is better
+	rank  int // how likely the candidate fits, lower is better
+}
+
+func (c *completer) add(label, text string, kind Kind, detail, doc string, rank int) {
+	match, ok := matches(label, c.prefix)
+	if !ok {
+		return
+	}
+	c.candidates = append(c.candidates, candidate{
+		Candidate: Candidate{
+			Label:  label,
+			Text:   text,
+			Kind:   kind,
+			Detail: detail,
+			Doc:    doc,
+			Span:   c.span,
+		},
+		match: match,
+		rank:  rank,
+	})
+}
+
+// fields adds the properties of s. Roots are read by bare names only, as a
+// quoted name is a string. Properties that may be of type want rank first,
+// followed by required ones.
+func (c *completer) fields(s *schema.Schema, root bool, want types.Type) {
+	for _, name := range s.Names() {
+		text, ok := parser.KeyText(name)
+		if !ok || root && text != name {
+			continue
+		}
+		prop, _ := s.Property(name)
+		t := types.FromSchema(prop)
+		rank := 0
+		if !t.Overlaps(want) {
+			rank += 2
+		}
+		if !s.IsRequired(name) {
+			rank++
+		}
+		doc := ""
+		if prop != nil {
+			doc = prop.Description
+		}
+		c.add(name, text, FIELD_KIND, t.String(), doc, rank)
+	}
+}
+
+// functions adds the functions of the registry, those accepting arg first.
+func (c *completer) functions(arg types.Type) {
+	if c.fns == nil {
+		return
+	}
+	arg = arg.Without(types.NULL)
+	for _, name := range c.fns.Names() {
+		f, _ := c.fns.Lookup(name)
+		rank := 0
+		if arg != types.NEVER && !f.Accepts.Overlaps(arg) {
+			rank = 1
+		}
+		c.add(name, name, FUNCTION_KIND, f.Accepts.String()+" -> "+f.Returns.String(), f.Doc, rank)
+	}
+}
+
+func (c *completer) ranked() []Candidate {
+	sort.SliceStable(c.candidates, func(i, j int) bool {
+		a, b := c.candidates[i], c.candidates[j]
+		if a.match != b.match {
+			return a.match < b.match
+		}
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		return a.Label < b.Label
+	})
+	out := make([]Candidate, len(c.candidates))
+	for i, cand := range c.candidates {
+		out[i] = cand.Candidate
+	}
+	return out
+}
+
+// matches reports whether label matches the typed prefix and how well:
+// 0 for a prefix, 1 for a prefix ignoring case and 2 for the letters of the
+// prefix in order starting with the first, e.g. tUp for toUpper.
+func matches(label, prefix string) (int, bool) {
+	if strings.HasPrefix(label, prefix) {
+		return 0, true
+	}
+	l, p := strings.ToLower(label), strings.ToLower(prefix)
+	if strings.HasPrefix(l, p) {
+		return 1, true
+	}
+	if first, _ := utf8.DecodeRuneInString(p); !strings.HasPrefix(l, string(first)) {
+		return 0, false
+	}
+	for _, r := range p {
+		i := strings.IndexRune(l, r)
+		if i < 0 {
+			return 0, false
+		}
+		l = l[i+utf8.RuneLen(r):]
+	}
+	return 2, true
+}
+
+// typeOf returns the type of the expression ending with tokens: an input
+// path typed by the schema or the result of a function. It is ANY if unknown.
+func (c *completer) typeOf(tokens []tokenizer.Token) types.Type {
+	n := len(tokens)
+	if n >= 2 && tokens[n-1].T == tokenizer.TEXT && !tokens[n-1].Quoted && tokens[n-2].T == tokenizer.PIPE {
+		if c.fns == nil {
+			return types.ANY
+		}
+		f, ok := c.fns.Lookup(tokens[n-1].Literal)
+		if !ok {
+			return types.ANY
+		}
+		return f.ResultType(c.typeOf(tokens[:n-2]))
+	}
+	if n >= 1 && tokens[n-1].Quoted {
+		return types.STRING
+	}
+	s, ok := c.resolve(tokens)
+	if !ok {
+		return types.ANY
+	}
+	return types.FromSchema(s)
+}
+
+// resolve returns the schema of the input path ending with tokens, e.g.
+// user.tags[0], reporting false if tokens do not end with a path.
+func (c *completer) resolve(tokens []tokenizer.Token) (*schema.Schema, bool) {
+	keys, ok := pathBefore(tokens)
+	if !ok {
+		return nil, false
+	}
+	s := c.schema
+	for _, key := range keys {
+		if isArray(s) {
+			s = s.Element()
+			continue
+		}
+		prop, ok := s.Property(key)
+		if !ok {
+			return nil, false
+		}
+		s = prop
+	}
+	return s, true
+}
+
+// pathBefore returns the keys of the input path ending with tokens.
+func pathBefore(tokens []tokenizer.Token) ([]string, bool) {
+	var keys []string
+	i := len(tokens) - 1
+	for i >= 0 {
+		switch {
+		case tokens[i].T == tokenizer.TEXT:
+			keys = append(keys, tokens[i].Literal)
+			i--
+		case tokens[i].T == tokenizer.RBRACKET && i >= 2 && tokens[i-1].T == tokenizer.TEXT && tokens[i-2].T == tokenizer.LBRACKET:
+			keys = append(keys, tokens[i-1].Literal)
+			i -= 3
+			continue
+		default:
+			return nil, false
+		}
+		if i < 0 || tokens[i].T != tokenizer.DOT {
+			break
+		}
+		i--
+	}
+	// the root is a bare name, quoted it would be a string
+	if len(keys) == 0 || tokens[i+1].T != tokenizer.TEXT || tokens[i+1].Quoted {
+		return nil, false
+	}
+	for l, r := 0, len(keys)-1; l < r; l, r = l+1, r-1 {
+		keys[l], keys[r] = keys[r], keys[l]
+	}
+	return keys, true
+}
+
+func isArray(s *schema.Schema) bool {
+	return s != nil && s.Allows(schema.ARRAY) && !s.Allows(schema.OBJECT)
+}
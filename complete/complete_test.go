@@ -0,0 +1,121 @@
+package complete
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["user"],
+	"additionalProperties": false,
+	"properties": {
+		"user": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "description": "the full name"},
+				"age": {"type": "integer"},
+				"nickname": {"type": ["string", "null"]},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"first name": {"type": "string"},
+				"it's \"odd\"": {"type": "string"}
+			}
+		},
+		"items": {"type": "array", "items": {"type": "object", "properties": {"id": {"type": "integer"}}}},
+		"count": {"type": "number"},
+		"say \"hi\"": {"type": "string"}
+	}
+}`
+
+// CURSOR marks the offset completed in the test inputs.
+const CURSOR = "‸"
+
+func TestComplete(t *testing.T) {
+	s, err := schema.Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		input    string
+		context  Context
+		expected []string
+	}{
+		{`{{ user.‸`, FIELD, []string{"name", "age", "\"first name\"", "nickname", "tags"}},
+		{`{{ user.n‸`, FIELD, []string{"name", "nickname"}},
+		{`{{ user.N‸ }}`, FIELD, []string{"name", "nickname"}},
+		{`{{ user.nme‸`, FIELD, []string{"name", "nickname"}},
+		{`{{ user.fn‸`, FIELD, []string{"\"first name\""}},
+		{`{{ user[‸`, INDEX, []string{"name", "age", "\"first name\"", "nickname", "tags"}},
+		{`{{ items[0].‸`, FIELD, []string{"id"}},
+		{`{{ items.0.‸`, FIELD, []string{"id"}},
+		{`{{ items[‸`, INDEX, nil},
+		{`{{ ‸`, VALUE, []string{"user", "count", "items"}},
+		{`{{ (‸`, VALUE, []string{"user", "count", "items"}},
+		{`{{ a.{{‸`, VALUE, []string{"user", "count", "items"}},
+		{`{{ user.nickname ?? ‸`, FALLBACK, []string{"user", "count", "items"}},
+		{`{{ user.age ?? ‸`, FALLBACK, []string{"count", "user", "items"}},
+		{`{{ user.age ?? c‸`, FALLBACK, []string{"count"}},
+		{`{{ user.name | ‸`, FUNCTION, []string{"fromJson", "length", "toJson", "toLower", "toNumber", "toString", "toUpper", "trim", "keys"}},
+		{`{{ user.tags | ‸`, FUNCTION, []string{"length", "toJson", "toString", "fromJson", "keys", "toLower", "toNumber", "toUpper", "trim"}},
+		{`{{ user.name | toNumber | to‸`, FUNCTION, []string{"toJson", "toNumber", "toString", "toLower", "toUpper"}},
+		{`{{ user.name | tUp‸`, FUNCTION, []string{"toUpper"}},
+		{`{{ user.name | tU‸`, FUNCTION, []string{"toNumber", "toUpper"}},
+		{`{{ missing.‸`, FIELD, nil},
+		{`{{ "user".‸`, FIELD, nil},
+		{`{{ user ?? "a‸`, NONE, nil},
+		{`text ‸ {{ user }}`, NONE, nil},
+		{`{{ user }} ‸`, NONE, nil},
+		{`{{ user }} {{ user.‸ }}`, FIELD, []string{"name", "age", "\"first name\"", "nickname", "tags"}},
+		{`{{ user ‸`, NONE, nil},
+	}
+
+	for _, test := range tt {
+		offset := strings.Index(test.input, CURSOR)
+		src := strings.Replace(test.input, CURSOR, "", 1)
+		if ctx := ContextOf(src, offset); ctx != test.context {
+			t.Errorf("%s: wrong context, expected=%s got=%s", test.input, test.context, ctx)
+		}
+		var got []string
+		for _, c := range Complete(src, offset, s, functions.Default) {
+			got = append(got, c.Text)
+		}
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("%s: wrong candidates\nexpected=%q\ngot=%q", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestCandidate(t *testing.T) {
+	s, err := schema.Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := `{{ user.na }}`
+	got := Complete(src, 10, s, functions.Default)
+	expected := Candidate{
+		Label:  "name",
+		Text:   "name",
+		Kind:   FIELD_KIND,
+		Detail: "string",
+		Doc:    "the full name",
+		Span:   parser.Span{Start: 8, End: 10},
+	}
+	if len(got) != 2 || got[0] != expected || got[1].Label != "nickname" {
+		t.Fatalf("expected=%+v got=%+v", expected, got)
+	}
+
+	got = Complete(`{{ a | tri`, 10, nil, functions.Default)
+	if len(got) == 0 || got[0].Label != "trim" || got[0].Kind != FUNCTION_KIND || got[0].Detail != "string -> string" {
+		t.Fatalf("wrong function candidate, got=%+v", got)
+	}
+	if got := Complete(`{{ a.`, 5, nil, nil); len(got) != 0 {
+		t.Fatalf("expected no candidates without a schema, got=%+v", got)
+	}
+}
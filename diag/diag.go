@@ -0,0 +1,152 @@
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+	INFO
+)
+
+func (s Severity) String() string {
+	switch s {
+	case ERROR:
+		return "error"
+	case WARNING:
+		return "warning"
+	case INFO:
+		return "info"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// SYNTAX_ERROR is the code of diagnostics made from syntax errors.
+const SYNTAX_ERROR = "syntax-error"
+
+// Diagnostic is a problem found in a template at Span.
+type Diagnostic struct {
+	Span     parser.Span
+	Severity Severity
+	Code     string
+	Message  string
+	Notes    []Note
+	Fixes    []Fix
+}
+
+// Note is additional information on a Diagnostic at another Span, e.g. where
+// an unclosed delimiter was opened.
+type Note struct {
+	Span    parser.Span
+	Message string
+}
+
+// Fix is a set of edits to the source that resolves a Diagnostic. Unsafe
+// fixes, e.g. guesses at a misspelled name, are offered but never applied
+// automatically.
+type Fix struct {
+	Message string
+	Edits   []Edit
+	Unsafe  bool
+}
+
+// Edit replaces the source at Span with NewText.
+type Edit struct {
+	Span    parser.Span
+	NewText string
+}
+
+// Replacements returns an unsafe fix replacing span with each of texts, e.g.
+// the suggestions for a misspelled name.
+func Replacements(span parser.Span, texts []string) []Fix {
+	var fixes []Fix
+	for _, t := range texts {
+		fixes = append(fixes, Fix{
+			Message: fmt.Sprintf("replace with `%s`", t),
+			Edits:   []Edit{{Span: span, NewText: t}},
+			Unsafe:  true,
+		})
+	}
+	return fixes
+}
+
+// Shift returns d with its spans and those of its notes and fixes moved by
+// offset, e.g. from a wildcard checked on its own to where it starts in a
+// template.
+func Shift(d Diagnostic, offset int) Diagnostic {
+	move := func(s parser.Span) parser.Span {
+		return parser.Span{Start: s.Start + offset, End: s.End + offset}
+	}
+	d.Span = move(d.Span)
+	notes := make([]Note, len(d.Notes))
+	for i, n := range d.Notes {
+		notes[i] = Note{Span: move(n.Span), Message: n.Message}
+	}
+	d.Notes = notes
+	fixes := make([]Fix, len(d.Fixes))
+	for i, f := range d.Fixes {
+		fixes[i] = Fix{Message: f.Message, Edits: make([]Edit, len(f.Edits)), Unsafe: f.Unsafe}
+		for j, e := range f.Edits {
+			fixes[i].Edits[j] = Edit{Span: move(e.Span), NewText: e.NewText}
+		}
+	}
+	d.Fixes = fixes
+	return d
+}
+
+// FromError returns the diagnostic of a *parser.SyntaxError, reporting false
+// for other errors.
+func FromError(err error) (Diagnostic, bool) {
+	var serr *parser.SyntaxError
+	if !errors.As(err, &serr) {
+		return Diagnostic{}, false
+	}
+	d := Diagnostic{
+		Span:     serr.Span,
+		Severity: ERROR,
+		Code:     SYNTAX_ERROR,
+		Message:  serr.Message,
+	}
+	if serr.Open != nil {
+		d.Notes = []Note{{
+			Span:    parser.Span{Start: serr.Open.Start, End: serr.Open.End},
+			Message: fmt.Sprintf("`%s` opened here", serr.Open.Literal),
+		}}
+	}
+	return d, true
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s [%s]", d.Span.Start, d.Span.End, d.Severity, d.Message, d.Code)
+}
+
+// Apply returns src with the edits applied. Edits must not overlap; edits
+// overlapping an earlier one are skipped.
+func Apply(src string, edits []Edit) string {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Span.Start < sorted[j].Span.Start
+	})
+
+	var out strings.Builder
+	pos := 0
+	for _, e := range sorted {
+		if e.Span.Start < pos || e.Span.End > len(src) {
+			continue
+		}
+		out.WriteString(src[pos:e.Span.Start])
+		out.WriteString(e.NewText)
+		pos = e.Span.End
+	}
+	out.WriteString(src[pos:])
+	return out.String()
+}
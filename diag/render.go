@@ -0,0 +1,271 @@
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jorgepbrown/wildcard-tree/highlight"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Format selects how Render writes diagnostics.
+type Format int
+
+const (
+	// TEXT shows the source lines of a diagnostic with its span underlined.
+	TEXT Format = iota
+	// ANSI is TEXT with colors and a highlighted source.
+	ANSI
+	// JSON writes one JSON object per diagnostic and line.
+	JSON
+)
+
+const (
+	ANSI_RESET  = "\x1b[0m"
+	ANSI_BOLD   = "\x1b[1m"
+	ANSI_GUTTER = "\x1b[1;34m"
+)
+
+var severityColors = map[Severity]string{
+	ERROR:   "\x1b[1;31m",
+	WARNING: "\x1b[1;33m",
+	INFO:    "\x1b[1;36m",
+}
+
+// Render writes diags of the template src to w. name is where src was read
+// from and may be empty.
+func Render(w io.Writer, name, src string, diags []Diagnostic, f Format) error {
+	r := &renderer{name: name, src: src, lines: newLines(src), color: f == ANSI}
+	for _, d := range diags {
+		var err error
+		if f == JSON {
+			err = r.json(w, d)
+		} else {
+			err = r.text(w, d)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type renderer struct {
+	name  string
+	src   string
+	lines lines
+	color bool
+}
+
+// label is an underlined span below a source line.
+type label struct {
+	span    parser.Span
+	marker  byte
+	color   string
+	message string
+}
+
+func (r *renderer) text(w io.Writer, d Diagnostic) error {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s%s",
+		r.paint(severityColors[d.Severity], fmt.Sprintf("%s[%s]", d.Severity, d.Code)),
+		r.paint(ANSI_BOLD, ": "+d.Message))
+	out.WriteByte('\n')
+
+	start := r.lines.position(r.src, d.Span.Start)
+	location := start.String()
+	if r.name != "" {
+		location = r.name + ":" + location
+	}
+
+	labels := []label{{span: d.Span, marker: '^', color: severityColors[d.Severity]}}
+	for _, n := range d.Notes {
+		labels = append(labels, label{span: n.Span, marker: '-', color: ANSI_GUTTER, message: n.Message})
+	}
+	byLine := map[int][]label{}
+	var numbers []int
+	for _, l := range labels {
+		line := r.lines.position(r.src, l.span.Start).Line
+		if _, ok := byLine[line]; !ok {
+			numbers = append(numbers, line)
+		}
+		byLine[line] = append(byLine[line], l)
+	}
+	sort.Ints(numbers)
+
+	width := len(fmt.Sprint(numbers[len(numbers)-1]))
+	gutter := strings.Repeat(" ", width)
+	fmt.Fprintf(&out, "%s%s %s\n", gutter, r.paint(ANSI_GUTTER, "-->"), location)
+	fmt.Fprintf(&out, "%s %s\n", gutter, r.paint(ANSI_GUTTER, "|"))
+	for i, n := range numbers {
+		if i > 0 && n > numbers[i-1]+1 {
+			fmt.Fprintf(&out, "%s\n", r.paint(ANSI_GUTTER, "..."))
+		}
+		text := r.lines.text(r.src, n)
+		shown := text
+		if r.color {
+			shown = highlight.ANSI(text)
+		}
+		fmt.Fprintf(&out, "%s %s\n", r.paint(ANSI_GUTTER, fmt.Sprintf("%*d |", width, n)), shown)
+		for _, l := range byLine[n] {
+			fmt.Fprintf(&out, "%s %s %s\n", gutter, r.paint(ANSI_GUTTER, "|"), r.underline(text, r.lines.start(n), l))
+		}
+	}
+	for _, fix := range d.Fixes {
+		fmt.Fprintf(&out, "%s %s help: %s\n", gutter, r.paint(ANSI_GUTTER, "="), fix.Message)
+	}
+	out.WriteByte('\n')
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// underline returns the markers below the span of l in text, the line
+// starting at offset. Spans reaching past the line are cut at its end.
+func (r *renderer) underline(text string, offset int, l label) string {
+	start := max(l.span.Start-offset, 0)
+	end := min(max(l.span.End-offset, start), len(text))
+	start = min(start, len(text))
+
+	var out strings.Builder
+	// Keep tabs so that the markers line up with the source.
+	for _, c := range text[:start] {
+		if c == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+	markers := strings.Repeat(string(l.marker), max(utf8.RuneCountInString(text[start:end]), 1))
+	out.WriteString(r.paint(l.color, markers))
+	if l.message != "" {
+		out.WriteString(" " + r.paint(l.color, l.message))
+	}
+	return strings.TrimRight(out.String(), " ")
+}
+
+func (r *renderer) paint(color, s string) string {
+	if !r.color {
+		return s
+	}
+	return color + s + ANSI_RESET
+}
+
+type jsonPosition struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonNote struct {
+	Message string       `json:"message"`
+	Start   jsonPosition `json:"start"`
+	End     jsonPosition `json:"end"`
+}
+
+type jsonEdit struct {
+	Start   jsonPosition `json:"start"`
+	End     jsonPosition `json:"end"`
+	NewText string       `json:"newText"`
+}
+
+type jsonFix struct {
+	Message string     `json:"message"`
+	Edits   []jsonEdit `json:"edits"`
+	Unsafe  bool       `json:"unsafe,omitempty"`
+}
+
+type jsonDiagnostic struct {
+	File     string       `json:"file,omitempty"`
+	Severity string       `json:"severity"`
+	Code     string       `json:"code"`
+	Message  string       `json:"message"`
+	Start    jsonPosition `json:"start"`
+	End      jsonPosition `json:"end"`
+	Notes    []jsonNote   `json:"notes,omitempty"`
+	Fixes    []jsonFix    `json:"fixes,omitempty"`
+}
+
+func (r *renderer) json(w io.Writer, d Diagnostic) error {
+	out := jsonDiagnostic{
+		File:     r.name,
+		Severity: d.Severity.String(),
+		Code:     d.Code,
+		Message:  d.Message,
+		Start:    r.jsonPosition(d.Span.Start),
+		End:      r.jsonPosition(d.Span.End),
+	}
+	for _, n := range d.Notes {
+		out.Notes = append(out.Notes, jsonNote{
+			Message: n.Message,
+			Start:   r.jsonPosition(n.Span.Start),
+			End:     r.jsonPosition(n.Span.End),
+		})
+	}
+	for _, fix := range d.Fixes {
+		f := jsonFix{Message: fix.Message, Edits: []jsonEdit{}, Unsafe: fix.Unsafe}
+		for _, e := range fix.Edits {
+			f.Edits = append(f.Edits, jsonEdit{
+				Start:   r.jsonPosition(e.Span.Start),
+				End:     r.jsonPosition(e.Span.End),
+				NewText: e.NewText,
+			})
+		}
+		out.Fixes = append(out.Fixes, f)
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+func (r *renderer) jsonPosition(offset int) jsonPosition {
+	p := r.lines.position(r.src, offset)
+	return jsonPosition{Offset: offset, Line: p.Line, Column: p.Column}
+}
+
+// Position is a line and column, both starting at 1. Columns count runes.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// lines holds the offset of the start of every line of a source.
+type lines []int
+
+func newLines(src string) lines {
+	l := lines{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			l = append(l, i+1)
+		}
+	}
+	return l
+}
+
+func (l lines) position(src string, offset int) Position {
+	offset = min(max(offset, 0), len(src))
+	line := sort.Search(len(l), func(i int) bool { return l[i] > offset })
+	return Position{
+		Line:   line,
+		Column: utf8.RuneCountInString(src[l[line-1]:offset]) + 1,
+	}
+}
+
+// start returns the offset of the 1-based line n.
+func (l lines) start(n int) int {
+	return l[n-1]
+}
+
+// text returns the 1-based line n without its line break.
+func (l lines) text(src string, n int) string {
+	end := len(src)
+	if n < len(l) {
+		end = l[n] - 1
+	}
+	return strings.TrimSuffix(src[l[n-1]:end], "\r")
+}
@@ -0,0 +1,132 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestRenderText(t *testing.T) {
+	tt := []struct {
+		name     string
+		src      string
+		diags    func(src string) []Diagnostic
+		expected string
+	}{
+		{
+			name:  "syntax error",
+			src:   "{{ a.b[c }}",
+			diags: syntaxError,
+			expected: `error[syntax-error]: invalid wildcard syntax: expected ']' found '}}'
+ --> t.wc:1:10
+  |
+1 | {{ a.b[c }}
+  |          ^^
+  |       - ` + "`[`" + ` opened here
+
+`,
+		},
+		{
+			name: "note on another line",
+			src:  "{{ a ??\n\tb.c\n\n\n(d ?? e",
+			diags: func(src string) []Diagnostic {
+				return []Diagnostic{{
+					Span:    parser.Span{Start: len(src), End: len(src)},
+					Code:    SYNTAX_ERROR,
+					Message: "invalid wildcard syntax: expected '}}' found ''",
+					Notes:   []Note{{Span: parser.Span{Start: 0, End: 2}, Message: "`{{` opened here"}},
+				}}
+			},
+			expected: `error[syntax-error]: invalid wildcard syntax: expected '}}' found ''
+ --> t.wc:5:8
+  |
+1 | {{ a ??
+  | -- ` + "`{{`" + ` opened here
+...
+5 | (d ?? e
+  |        ^
+
+`,
+		},
+		{
+			name: "fixes",
+			src:  "{{ a ??\n\t'b' ?? c }}",
+			diags: func(string) []Diagnostic {
+				return []Diagnostic{{
+					Span:     parser.Span{Start: 16, End: 17},
+					Severity: WARNING,
+					Code:     "unreachable-fallback",
+					Message:  "fallback `c` is unreachable",
+					Fixes:    []Fix{{Message: "remove the fallback"}},
+				}}
+			},
+			expected: "warning[unreachable-fallback]: fallback `c` is unreachable\n" +
+				" --> t.wc:2:9\n" +
+				"  |\n" +
+				"2 | \t'b' ?? c }}\n" +
+				"  | \t       ^\n" +
+				"  = help: remove the fallback\n\n",
+		},
+	}
+
+	ansi := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	for _, tc := range tt {
+		diags := tc.diags(tc.src)
+		var text, colored bytes.Buffer
+		if err := Render(&text, "t.wc", tc.src, diags, TEXT); err != nil {
+			t.Fatal(err)
+		}
+		if text.String() != tc.expected {
+			t.Fatalf("%s: wrong rendering\nexpected:\n%s\ngot:\n%s", tc.name, tc.expected, text.String())
+		}
+		if err := Render(&colored, "t.wc", tc.src, diags, ANSI); err != nil {
+			t.Fatal(err)
+		}
+		if plain := ansi.ReplaceAllString(colored.String(), ""); plain != tc.expected {
+			t.Fatalf("%s: ANSI differs from text\nexpected:\n%s\ngot:\n%s", tc.name, tc.expected, plain)
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	src := "{{ 'é'.b[c }}"
+	var out bytes.Buffer
+	if err := Render(&out, "t.wc", src, syntaxError(src), JSON); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"file":     "t.wc",
+		"severity": "error",
+		"code":     SYNTAX_ERROR,
+		"message":  "invalid wildcard syntax: expected ']' found '}}'",
+		"start":    map[string]any{"offset": 12.0, "line": 1.0, "column": 12.0},
+		"end":      map[string]any{"offset": 14.0, "line": 1.0, "column": 14.0},
+		"notes": []any{map[string]any{
+			"message": "`[` opened here",
+			"start":   map[string]any{"offset": 9.0, "line": 1.0, "column": 9.0},
+			"end":     map[string]any{"offset": 10.0, "line": 1.0, "column": 10.0},
+		}},
+	}
+	want, _ := json.Marshal(expected)
+	have, _ := json.Marshal(got)
+	if string(want) != string(have) {
+		t.Fatalf("expected=%s\ngot=%s", want, have)
+	}
+}
+
+func syntaxError(src string) []Diagnostic {
+	_, err := parser.New(tokenizer.New(src)).Parse()
+	d, ok := FromError(err)
+	if !ok {
+		return nil
+	}
+	return []Diagnostic{d}
+}
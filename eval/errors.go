@@ -0,0 +1,25 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+const (
+	INVALID_FUNCTION_NAME = "function name must be a literal, got %s"
+	UNKNOWN_EXPR_TYPE     = "cannot evaluate expression type %s"
+	UNKNOWN_FUNCTION      = "unknown function %s"
+)
+
+func newInvalidFunctionNameError(e parser.Expression) error {
+	return fmt.Errorf(INVALID_FUNCTION_NAME, e.Literal())
+}
+
+func newUnknownExprTypeError(t parser.ExpressionType) error {
+	return fmt.Errorf(UNKNOWN_EXPR_TYPE, t)
+}
+
+func newUnknownFunctionError(name string) error {
+	return fmt.Errorf(UNKNOWN_FUNCTION, name)
+}
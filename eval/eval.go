@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"strconv"
+
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Evaluate evaluates e against the JSON decoded data.
+//
+// Quoted literals are strings and bare literals read the top level field of
+// data with that name. Keys of dot and index expressions are names unless they
+// are wildcards, whose value is used as the key. Reading a missing field,
+// indexing out of range or accessing a value that is not an object or array
+// results in null, which ?? replaces with its fallback. Functions are called
+// from fns; if it is nil every function is unknown.
+func Evaluate(e parser.Expression, data any, fns *functions.Registry) (any, error) {
+	switch v := e.(type) {
+	case *parser.Literal:
+		if v.Quoted {
+			return v.V, nil
+		}
+		return Access(data, v.V), nil
+	case *parser.Wildcard:
+		return Evaluate(v.Expression, data, fns)
+	case *parser.DotExpression:
+		return evaluateAccess(v.Target, v.Key, data, fns)
+	case *parser.IndexExpression:
+		return evaluateAccess(v.Target, v.Key, data, fns)
+	case *parser.NullCoalesceExpression:
+		primary, err := Evaluate(v.Primary, data, fns)
+		if err != nil {
+			return nil, err
+		}
+		if primary != nil {
+			return primary, nil
+		}
+		return Evaluate(v.Fallback, data, fns)
+	case *parser.FunctionExpression:
+		arg, err := Evaluate(v.Argument, data, fns)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := v.Name.(*parser.Literal)
+		if !ok {
+			return nil, newInvalidFunctionNameError(v.Name)
+		}
+		if fns == nil {
+			return nil, newUnknownFunctionError(name.V)
+		}
+		return fns.Call(name.V, arg)
+	}
+	return nil, newUnknownExprTypeError(e.Type())
+}
+
+func evaluateAccess(target, key parser.Expression, data any, fns *functions.Registry) (any, error) {
+	t, err := Evaluate(target, data, fns)
+	if err != nil {
+		return nil, err
+	}
+	k, err := Key(key, data, fns)
+	if err != nil {
+		return nil, err
+	}
+	return Access(t, k), nil
+}
+
+// Key returns the key named by e in a dot or index expression.
+func Key(e parser.Expression, data any, fns *functions.Registry) (string, error) {
+	if l, ok := e.(*parser.Literal); ok {
+		return l.V, nil
+	}
+	v, err := Evaluate(e, data, fns)
+	if err != nil {
+		return "", err
+	}
+	return ToKey(v), nil
+}
+
+// ToKey converts an evaluated value to a key.
+func ToKey(v any) string {
+	switch k := v.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(k)
+	}
+	return ""
+}
+
+// Access returns the field key of an object or the element at index key of an
+// array, or nil if there is none.
+func Access(v any, key string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return t[key]
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(t) {
+			return nil
+		}
+		return t[i]
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package eval
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+const testData = `{
+	"user": {"name": "ada", "nickname": null, "tags": ["a", "b"]},
+	"key": "name",
+	"index": 1,
+	"items": [{"id": 1}, {"id": 2}]
+}`
+
+func TestEvaluate(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(testData), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		input    string
+		expected any
+		err      string
+	}{
+		{`{{"user"}}`, "user", ""},
+		{"{{user.name}}", "ada", ""},
+		{"{{user.missing}}", nil, ""},
+		{"{{missing.deeply.nested}}", nil, ""},
+		{`{{user["name"]}}`, "ada", ""},
+		{"{{user.{{key}}}}", "ada", ""},
+		{"{{user.tags[1]}}", "b", ""},
+		{"{{user.tags[{{index}}]}}", "b", ""},
+		{"{{user.tags[5]}}", nil, ""},
+		{"{{items.0.id}}", float64(1), ""},
+		{`{{user.nickname ?? "anonymous"}}`, "anonymous", ""},
+		{"{{user.nickname ?? user.name}}", "ada", ""},
+		{"{{user.name | toUpper}}", "ADA", ""},
+		{"{{user.tags | length}}", float64(2), ""},
+		{`{{user.nickname | toUpper ?? "x"}}`, nil, "function name must be a literal, got (toUpper ?? \"x\")"},
+		{"{{user.name | toupper}}", nil, "unknown function toupper, did you mean toUpper?"},
+		{"{{user.tags | toUpper}}", nil, "toUpper expects string, got array"},
+	}
+
+	for i, test := range tt {
+		t.Logf("eval-%d %s", i, test.input)
+		ast, err := parser.New(tokenizer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := Evaluate(ast.Root, data, functions.Default)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("wrong error, expected=%s got=%v", test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("wrong result, expected=%v got=%v", test.expected, actual)
+		}
+	}
+}
+
+func TestEvaluateWithoutFunctions(t *testing.T) {
+	ast, err := parser.New(tokenizer.New("{{ a | toUpper }}")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Evaluate(ast.Root, nil, nil); err == nil || err.Error() != "unknown function toUpper" {
+		t.Fatalf("wrong error, expected=unknown function toUpper got=%v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package extract
+
+import (
+	"fmt"
+)
+
+const (
+	UNKNOWN_FORMAT = "cannot extract wildcards from %s, expected a .json, .yaml or .yml file"
+)
+
+func newUnknownFormatError(path string) error {
+	return fmt.Errorf(UNKNOWN_FORMAT, path)
+}
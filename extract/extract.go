@@ -0,0 +1,143 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Position is a location in a document. Line and Column start at 1 and Column
+// counts bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Result is a string value of a document containing wildcards, parsed as a
+// template. Path is the document path of the value, e.g. steps[3].input.url,
+// and Pos where the value starts in the document. Errs are the syntax errors
+// of the wildcards that do not parse, which are left out of Template.
+type Result struct {
+	Path     string
+	Value    string
+	Pos      Position
+	Template parser.Template
+	Errs     []error
+
+	// offsets holds the document offset of every byte of Value and of its
+	// end, or -1 where it is unknown.
+	offsets []int
+	lines   lineIndex
+}
+
+// Position returns the document position of the byte offset of Value, e.g.
+// the Start of a wildcard of the template. Offsets that cannot be mapped back
+// to the document, such as inside escape sequences YAML rewrote, resolve to
+// Pos.
+func (r *Result) Position(offset int) Position {
+	if offset < 0 || offset >= len(r.offsets) || r.offsets[offset] < 0 {
+		return r.Pos
+	}
+	return r.lines.position(r.offsets[offset])
+}
+
+// ErrorPosition returns the document position of err, one of Errs, or Pos if
+// it has no span.
+func (r *Result) ErrorPosition(err error) Position {
+	var serr *parser.SyntaxError
+	if !errors.As(err, &serr) {
+		return r.Pos
+	}
+	return r.Position(serr.Span.Start)
+}
+
+// File extracts the wildcards of a JSON or YAML file, chosen by its extension.
+func File(path string) ([]Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON(data)
+	case ".yaml", ".yml":
+		return YAML(data)
+	}
+	return nil, newUnknownFormatError(path)
+}
+
+func newResult(path, value string, start int, offsets []int, lines lineIndex) Result {
+	r := Result{
+		Path:    path,
+		Value:   value,
+		Pos:     lines.position(start),
+		offsets: offsets,
+		lines:   lines,
+	}
+	r.Template, r.Errs = parser.ParseTemplateAll(value)
+	return r
+}
+
+// lineIndex holds the offset of the start of every line of a document.
+type lineIndex []int
+
+func newLineIndex(data []byte) lineIndex {
+	lines := lineIndex{0}
+	for i, b := range data {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func (l lineIndex) position(offset int) Position {
+	line := sort.Search(len(l), func(i int) bool { return l[i] > offset }) - 1
+	return Position{Offset: offset, Line: line + 1, Column: offset - l[line] + 1}
+}
+
+// offset returns the document offset of a 1-based line and column.
+func (l lineIndex) offset(line, column int) int {
+	if line < 1 || line > len(l) {
+		return 0
+	}
+	return l[line-1] + column - 1
+}
+
+func joinKey(path, key string) string {
+	if !isIdentifier(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func joinIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// expected is a wildcard found at path, at line:column of the document.
+type expected struct {
+	path     string
+	wildcard string
+	pos      string
+}
+
+func TestJSON(t *testing.T) {
+	doc := `{
+  "name": "deploy {{ env }}",
+  "steps": [
+    {"run": "build"},
+    {"input": {"url": "https://{{ host }}/{{ \"v1\" }}/{{ id }}"}},
+    {"with space": "é{{ a ?? 'b' }}"}
+  ],
+  "count": 3
+}`
+	results, err := JSON([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, results, []expected{
+		{"name", "{{ env }}", "2:19"},
+		{"steps[1].input.url", "{{ host }}", "5:32"},
+		{"steps[1].input.url", `{{ "v1" }}`, "5:43"},
+		{"steps[1].input.url", "{{ id }}", "5:56"},
+		{`steps[2]["with space"]`, "{{ a ?? 'b' }}", "6:23"},
+	})
+}
+
+func TestYAML(t *testing.T) {
+	doc := `name: deploy {{ env }}
+steps:
+  - run: build
+  - input:
+      url: "https://{{ host }}/\t{{ id }}"
+  - script: |
+      echo {{ cmd | trim }}
+  - count: 3
+`
+	results, err := YAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, results, []expected{
+		{"name", "{{ env }}", "1:14"},
+		{"steps[1].input.url", "{{ host }}", "5:21"},
+		{"steps[1].input.url", "{{ id }}", "5:34"},
+		{"steps[2].script", "{{ cmd | trim }}", "7:12"},
+	})
+}
+
+func TestSyntaxError(t *testing.T) {
+	results, err := JSON([]byte(`["ok", "{{ a", "{{ b }} {{ c ?? }} {{ d }}"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Path != "[1]" || len(results[0].Errs) != 1 {
+		t.Fatalf("expected a syntax error at [1], got %+v", results)
+	}
+	if pos := results[0].ErrorPosition(results[0].Errs[0]).String(); pos != "1:13" {
+		t.Fatalf("wrong position, expected=1:13 got=%s", pos)
+	}
+
+	// the wildcards around a syntax error are kept
+	r := results[1]
+	if len(r.Errs) != 1 || len(r.Template.Wildcards) != 2 {
+		t.Fatalf("expected one error and two wildcards, got %+v", r)
+	}
+	if pos := r.ErrorPosition(r.Errs[0]).String(); pos != "1:33" {
+		t.Fatalf("wrong position, expected=1:33 got=%s", pos)
+	}
+	if pos := r.Position(r.Template.Wildcards[1].Start).String(); pos != "1:36" {
+		t.Fatalf("wrong position, expected=1:36 got=%s", pos)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workflow.yml")
+	if err := os.WriteFile(path, []byte("a: '{{ b }}'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := File(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, results, []expected{{"a", "{{ b }}", "1:5"}})
+
+	if _, err := File(filepath.Join(dir, "workflow.txt")); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func checkResults(t *testing.T, results []Result, tt []expected) {
+	t.Helper()
+	i := 0
+	for _, r := range results {
+		if len(r.Errs) > 0 {
+			t.Fatalf("%s: %v", r.Path, r.Errs)
+		}
+		for _, w := range r.Template.Wildcards {
+			if i >= len(tt) {
+				t.Fatalf("unexpected wildcard %s at %s", r.Value[w.Start:w.End], r.Path)
+			}
+			tc := tt[i]
+			i++
+			if r.Path != tc.path {
+				t.Fatalf("wrong path, expected=%s got=%s", tc.path, r.Path)
+			}
+			if got := r.Value[w.Start:w.End]; got != tc.wildcard {
+				t.Fatalf("%s: wrong wildcard, expected=%s got=%s", tc.path, tc.wildcard, got)
+			}
+			if pos := r.Position(w.Start).String(); pos != tc.pos {
+				t.Fatalf("%s: wrong position of %s, expected=%s got=%s", tc.path, tc.wildcard, tc.pos, pos)
+			}
+		}
+	}
+	if i != len(tt) {
+		t.Fatalf("wrong number of wildcards, expected=%d got=%d", len(tt), i)
+	}
+}
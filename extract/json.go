@@ -0,0 +1,158 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// JSON extracts every string value of a JSON document containing {{, in
+// document order.
+func JSON(data []byte) ([]Result, error) {
+	s := &jsonScanner{
+		data:  data,
+		dec:   json.NewDecoder(bytes.NewReader(data)),
+		lines: newLineIndex(data),
+	}
+	s.dec.UseNumber()
+	if err := s.value(""); err != nil {
+		return nil, err
+	}
+	return s.results, nil
+}
+
+type jsonScanner struct {
+	data    []byte
+	dec     *json.Decoder
+	lines   lineIndex
+	results []Result
+}
+
+func (s *jsonScanner) value(path string) error {
+	before := int(s.dec.InputOffset())
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			for s.dec.More() {
+				key, err := s.dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := s.value(joinKey(path, key.(string))); err != nil {
+					return err
+				}
+			}
+		} else {
+			for i := 0; s.dec.More(); i++ {
+				if err := s.value(joinIndex(path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		// the closing delimiter
+		_, err := s.dec.Token()
+		return err
+	case string:
+		if !strings.Contains(v, "{{") {
+			return nil
+		}
+		// Only separators are between the previous token and the quote.
+		start := before + bytes.IndexByte(s.data[before:], '"')
+		end := int(s.dec.InputOffset())
+		offsets := unquoteOffsets(s.data[start:end], start, v)
+		s.results = append(s.results, newResult(path, v, start, offsets, s.lines))
+	}
+	return nil
+}
+
+// unquoteOffsets returns the document offset of every byte of the decoded
+// JSON string value and of its end. raw is the quoted string found at base.
+// Bytes produced by an escape sequence map to its backslash.
+func unquoteOffsets(raw []byte, base int, value string) []int {
+	offsets := make([]int, 0, len(value)+1)
+	decoded := make([]byte, 0, len(value))
+	for i := 1; i < len(raw)-1; {
+		if raw[i] != '\\' {
+			decoded = append(decoded, raw[i])
+			offsets = append(offsets, base+i)
+			i++
+			continue
+		}
+		n := len(decoded)
+		switch raw[i+1] {
+		case 'b':
+			decoded = append(decoded, '\b')
+		case 'f':
+			decoded = append(decoded, '\f')
+		case 'n':
+			decoded = append(decoded, '\n')
+		case 'r':
+			decoded = append(decoded, '\r')
+		case 't':
+			decoded = append(decoded, '\t')
+		case 'u':
+			r, size := unescapeRune(raw[i:])
+			decoded = utf8.AppendRune(decoded, r)
+			for ; n < len(decoded); n++ {
+				offsets = append(offsets, base+i)
+			}
+			i += size
+			continue
+		default:
+			decoded = append(decoded, raw[i+1])
+		}
+		offsets = append(offsets, base+i)
+		i += 2
+	}
+	offsets = append(offsets, base+len(raw)-1)
+	// json replaces invalid UTF-8 and we do not, give up rather than
+	// report wrong positions.
+	if string(decoded) != value {
+		for i := range offsets {
+			offsets[i] = -1
+		}
+	}
+	return offsets
+}
+
+// unescapeRune decodes the \uXXXX escape at the start of b, combined with a
+// following escape if they form a surrogate pair.
+func unescapeRune(b []byte) (rune, int) {
+	r := hexRune(b)
+	if !utf16.IsSurrogate(r) {
+		return r, 6
+	}
+	if len(b) >= 12 && b[6] == '\\' && b[7] == 'u' {
+		if pair := utf16.DecodeRune(r, hexRune(b[6:])); pair != utf8.RuneError {
+			return pair, 12
+		}
+	}
+	return utf8.RuneError, 6
+}
+
+func hexRune(b []byte) rune {
+	if len(b) < 6 {
+		return utf8.RuneError
+	}
+	var r rune
+	for _, c := range b[2:6] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return utf8.RuneError
+		}
+		r = r<<4 | rune(c)
+	}
+	return r
+}
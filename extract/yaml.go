@@ -0,0 +1,107 @@
+package extract
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML extracts every string scalar of a YAML document containing {{, in
+// document order. Aliases are not followed, their anchor is reported once.
+//
+// YAML rewrites scalars in more ways than JSON, so positions are only exact
+// where the scalar or the wildcard appears verbatim in the document, which
+// holds for plain and quoted scalars without escapes and for block scalars.
+func YAML(data []byte) ([]Result, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	s := &yamlScanner{data: data, lines: newLineIndex(data)}
+	s.collect(&doc)
+	sort.Ints(s.starts)
+	s.walk(&doc, "")
+	return s.results, nil
+}
+
+type yamlScanner struct {
+	data    []byte
+	lines   lineIndex
+	starts  []int
+	results []Result
+}
+
+// collect records the start of every node, which bounds the raw text of the
+// scalar before it.
+func (s *yamlScanner) collect(n *yaml.Node) {
+	if n.Kind != yaml.DocumentNode {
+		s.starts = append(s.starts, s.lines.offset(n.Line, n.Column))
+	}
+	for _, c := range n.Content {
+		s.collect(c)
+	}
+}
+
+func (s *yamlScanner) walk(n *yaml.Node, path string) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			s.walk(c, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			s.walk(n.Content[i+1], joinKey(path, n.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			s.walk(c, joinIndex(path, i))
+		}
+	case yaml.ScalarNode:
+		if n.Tag == "!!str" && strings.Contains(n.Value, "{{") {
+			s.scalar(n, path)
+		}
+	}
+}
+
+func (s *yamlScanner) scalar(n *yaml.Node, path string) {
+	start := s.lines.offset(n.Line, n.Column)
+	end := len(s.data)
+	if i := sort.SearchInts(s.starts, start+1); i < len(s.starts) {
+		end = s.starts[i]
+	}
+	raw := s.data[start:end]
+
+	offsets := make([]int, len(n.Value)+1)
+	for i := range offsets {
+		offsets[i] = -1
+	}
+	content := 0
+	if n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+		content = 1
+	}
+	if bytes.HasPrefix(raw[content:], []byte(n.Value)) {
+		for i := range offsets {
+			offsets[i] = start + content + i
+		}
+	}
+
+	r := newResult(path, n.Value, start, offsets, s.lines)
+	if offsets[0] < 0 {
+		// Find the wildcards one after another in the raw text instead.
+		from := 0
+		for _, w := range r.Template.Wildcards {
+			i := bytes.Index(raw[from:], []byte(n.Value[w.Start:w.End]))
+			if i < 0 {
+				continue
+			}
+			from += i
+			for j := w.Start; j <= w.End; j++ {
+				offsets[j] = start + from + j - w.Start
+			}
+			from += w.End - w.Start
+		}
+	}
+	s.results = append(s.results, r)
+}
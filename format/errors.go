@@ -0,0 +1,9 @@
+package format
+
+import "fmt"
+
+const UNQUOTABLE_LITERAL = "literal %q cannot be written quoted"
+
+func newUnquotableLiteralError(v string) error {
+	return fmt.Errorf(UNQUOTABLE_LITERAL, v)
+}
@@ -0,0 +1,114 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Source parses src and returns it in canonical form.
+func Source(src string) (string, error) {
+	ast, err := parser.New(tokenizer.New(src)).Parse()
+	if err != nil {
+		return "", err
+	}
+	return Node(ast.Root)
+}
+
+// Template returns the source of tmpl with every wildcard in canonical form
+// and the text between them unchanged.
+func Template(tmpl parser.Template) (string, error) {
+	var out strings.Builder
+	last := 0
+	for _, w := range tmpl.Wildcards {
+		s, err := Node(w)
+		if err != nil {
+			return "", err
+		}
+		span := w.Pos()
+		out.WriteString(tmpl.Source[last:span.Start])
+		out.WriteString(s)
+		last = span.End
+	}
+	out.WriteString(tmpl.Source[last:])
+	return out.String(), nil
+}
+
+// Node prints e in canonical form: wildcards are padded with one space inside
+// the braces, ?? and | with one space on each side, and parentheses are only
+// written where the tree would parse differently without them. A literal
+// that cannot be quoted is an error, see parser.Quote.
+func Node(e parser.Expression) (string, error) {
+	p := &printer{}
+	p.write(e)
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.out.String(), nil
+}
+
+// printer writes a tree, keeping the first error.
+type printer struct {
+	out strings.Builder
+	err error
+}
+
+func (p *printer) write(e parser.Expression) {
+	out := &p.out
+	switch v := e.(type) {
+	case *parser.Wildcard:
+		out.WriteString("{{ ")
+		p.write(v.Expression)
+		out.WriteString(" }}")
+	case *parser.DotExpression:
+		p.operand(v.Target, parser.INDEX)
+		out.WriteByte('.')
+		p.operand(v.Key, parser.PAREN)
+	case *parser.IndexExpression:
+		p.operand(v.Target, parser.INDEX)
+		out.WriteByte('[')
+		p.operand(v.Key, parser.PAREN)
+		out.WriteByte(']')
+	case *parser.NullCoalesceExpression:
+		p.operand(v.Primary, parser.NULL)
+		out.WriteString(" ?? ")
+		p.operand(v.Fallback, parser.NULL+1)
+	case *parser.FunctionExpression:
+		p.operand(v.Argument, parser.PIPE)
+		out.WriteString(" | ")
+		p.operand(v.Name, parser.PIPE+1)
+	case *parser.Literal:
+		if _, ok := parser.Quote(v.V); v.Quoted && !ok && p.err == nil {
+			p.err = newUnquotableLiteralError(v.V)
+		}
+		out.WriteString(v.Literal())
+	default:
+		out.WriteString(e.Literal())
+	}
+}
+
+// operand writes e, in parentheses if it binds looser than prio.
+func (p *printer) operand(e parser.Expression, prio parser.OperatorPriority) {
+	if priority(e) >= prio {
+		p.write(e)
+		return
+	}
+	p.out.WriteByte('(')
+	p.write(e)
+	p.out.WriteByte(')')
+}
+
+// priority returns the priority of the operator of e, which is PAREN for
+// literals and wildcards as they never need parentheses.
+func priority(e parser.Expression) parser.OperatorPriority {
+	switch e.(type) {
+	case *parser.DotExpression, *parser.IndexExpression:
+		return parser.INDEX
+	case *parser.NullCoalesceExpression:
+		return parser.NULL
+	case *parser.FunctionExpression:
+		return parser.PIPE
+	}
+	return parser.PAREN
+}
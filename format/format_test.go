@@ -0,0 +1,74 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestSource(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{"{{a}}", "{{ a }}"},
+		{"{{  a.b[c]  }}", "{{ a.b[c] }}"},
+		{"{{a??'b'|toUpper}}", `{{ a ?? "b" | toUpper }}`},
+		{`{{ 'say "hi"' }}`, `{{ 'say "hi"' }}`},
+		{"{{ a.{{b}}[{{ c ?? d }}] }}", "{{ a.{{ b }}[{{ c ?? d }}] }}"},
+		{"{{ (a) }}", "{{ a }}"},
+		{"{{ (a ?? b) ?? c }}", "{{ a ?? b ?? c }}"},
+		{"{{ a ?? (b ?? c) }}", "{{ a ?? (b ?? c) }}"},
+		{"{{ (a | f) ?? b }}", "{{ (a | f) ?? b }}"},
+		{"{{ (a ?? b).c }}", "{{ (a ?? b).c }}"},
+		{"{{ a.(b.c) }}", "{{ a.(b.c) }}"},
+		{"{{ a[(b ?? c)] }}", "{{ a[(b ?? c)] }}"},
+		{"{{ a | (f | g) }}", "{{ a | (f | g) }}"},
+		{"{{ (a | f) | g }}", "{{ a | f | g }}"},
+	}
+
+	for _, tc := range tt {
+		got, err := Source(tc.input)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.input, err)
+		}
+		if got != tc.expected {
+			t.Fatalf("%s: expected=%s got=%s", tc.input, tc.expected, got)
+		}
+
+		// The canonical form parses to the same tree and is stable.
+		before, _ := parser.New(tokenizer.New(tc.input)).Parse()
+		after, err := parser.New(tokenizer.New(got)).Parse()
+		if err != nil {
+			t.Fatalf("%s: canonical form does not parse: %s", got, err)
+		}
+		if d := parser.Diff(before.Root, after.Root, parser.IgnoreSpans()); d != nil {
+			t.Fatalf("%s: canonical form changes the tree, %s", tc.input, d)
+		}
+		if again, _ := Node(after.Root); again != got {
+			t.Fatalf("%s: not stable, expected=%s got=%s", tc.input, got, again)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := parser.ParseTemplate("\n  a {{a}} and {{ (b)??c }} }}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Template(tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\n  a {{ a }} and {{ b ?? c }} }}\n"; got != expected {
+		t.Fatalf("expected=%q got=%q", expected, got)
+	}
+}
+
+func TestNodeUnquotable(t *testing.T) {
+	w := &parser.Wildcard{Expression: &parser.Literal{V: `it's "hi"`, Quoted: true}}
+	if got, err := Node(w); err == nil {
+		t.Fatalf("expected an error, got=%s", got)
+	}
+}
@@ -0,0 +1,158 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jorgepbrown/wildcard-tree/types"
+)
+
+// Default holds the builtin functions.
+var Default = NewRegistry(Builtins()...)
+
+// Builtins returns a new copy of the builtin functions.
+func Builtins() []*Function {
+	return []*Function{
+		{
+			Name:    "toUpper",
+			Doc:     "converts a string to upper case",
+			Accepts: types.STRING,
+			Returns: types.STRING,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				return strings.ToUpper(arg.(string)), nil
+			},
+		},
+		{
+			Name:    "toLower",
+			Doc:     "converts a string to lower case",
+			Accepts: types.STRING,
+			Returns: types.STRING,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				return strings.ToLower(arg.(string)), nil
+			},
+		},
+		{
+			Name:    "trim",
+			Doc:     "removes leading and trailing white space",
+			Accepts: types.STRING,
+			Returns: types.STRING,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				return strings.TrimSpace(arg.(string)), nil
+			},
+		},
+		{
+			Name:    "length",
+			Doc:     "returns the number of characters, elements or keys",
+			Accepts: types.STRING | types.ARRAY | types.OBJECT,
+			Returns: types.NUMBER,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				switch v := arg.(type) {
+				case string:
+					return float64(utf8.RuneCountInString(v)), nil
+				case []any:
+					return float64(len(v)), nil
+				case map[string]any:
+					return float64(len(v)), nil
+				}
+				return nil, newInvalidArgumentError("length", types.STRING|types.ARRAY|types.OBJECT, types.Of(arg))
+			},
+		},
+		{
+			Name:    "toString",
+			Doc:     "converts a value to its string form",
+			Accepts: types.ANY,
+			Returns: types.STRING,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				switch v := arg.(type) {
+				case string:
+					return v, nil
+				case nil:
+					return "", nil
+				case float64:
+					return strconv.FormatFloat(v, 'f', -1, 64), nil
+				case bool:
+					return strconv.FormatBool(v), nil
+				}
+				b, err := json.Marshal(arg)
+				if err != nil {
+					return nil, err
+				}
+				return string(b), nil
+			},
+		},
+		{
+			Name:    "toNumber",
+			Doc:     "parses a string as a number",
+			Accepts: types.STRING | types.NUMBER,
+			Returns: types.NUMBER,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				if s, ok := arg.(string); ok {
+					f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+					if err != nil {
+						return nil, newInvalidValueError("toNumber", s)
+					}
+					return f, nil
+				}
+				return arg, nil
+			},
+		},
+		{
+			Name:    "toJson",
+			Doc:     "encodes a value as JSON",
+			Accepts: types.ANY,
+			Returns: types.STRING,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				b, err := json.Marshal(arg)
+				if err != nil {
+					return nil, err
+				}
+				return string(b), nil
+			},
+		},
+		{
+			Name:    "fromJson",
+			Doc:     "decodes a JSON string",
+			Accepts: types.STRING,
+			Returns: types.ANY,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				var v any
+				if err := json.Unmarshal([]byte(arg.(string)), &v); err != nil {
+					return nil, fmt.Errorf("fromJson: %w", err)
+				}
+				return v, nil
+			},
+		},
+		{
+			Name:    "keys",
+			Doc:     "returns the sorted keys of an object",
+			Accepts: types.OBJECT,
+			Returns: types.ARRAY,
+			Pure:    true,
+			Call: func(arg any) (any, error) {
+				m := arg.(map[string]any)
+				keys := make([]string, 0, len(m))
+				for k := range m {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+				out := make([]any, len(keys))
+				for i, k := range keys {
+					out[i] = k
+				}
+				return out, nil
+			},
+		},
+	}
+}
@@ -0,0 +1,26 @@
+package functions
+
+import (
+	"fmt"
+
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+	"github.com/jorgepbrown/wildcard-tree/types"
+)
+
+const (
+	UNKNOWN_FUNCTION = "unknown function %s"
+	INVALID_ARGUMENT = "%s expects %s, got %s"
+	INVALID_VALUE    = "%s cannot convert %q"
+)
+
+func newUnknownFunctionError(name string, suggestions []string) error {
+	return fmt.Errorf(UNKNOWN_FUNCTION+"%s", name, suggest.DidYouMean(suggestions, nil))
+}
+
+func newInvalidArgumentError(name string, expected, found types.Type) error {
+	return fmt.Errorf(INVALID_ARGUMENT, name, expected, found)
+}
+
+func newInvalidValueError(name string, v string) error {
+	return fmt.Errorf(INVALID_VALUE, name, v)
+}
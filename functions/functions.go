@@ -0,0 +1,115 @@
+package functions
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+	"github.com/jorgepbrown/wildcard-tree/types"
+)
+
+// Function is a function that can be applied with a pipe, a | toUpper.
+type Function struct {
+	Name    string
+	Doc     string
+	Accepts types.Type
+	Returns types.Type
+	// Pure functions always return the same result for the same argument and
+	// can be evaluated ahead of time.
+	Pure bool
+	Call func(arg any) (any, error)
+}
+
+// Apply calls f with arg. Null arguments result in null unless f accepts null.
+func (f *Function) Apply(arg any) (any, error) {
+	t := types.Of(arg)
+	if t == types.NULL && !f.Accepts.Has(types.NULL) {
+		return nil, nil
+	}
+	if !f.Accepts.Has(t) {
+		return nil, newInvalidArgumentError(f.Name, f.Accepts, t)
+	}
+	return f.Call(arg)
+}
+
+// Source returns the Go source file and line implementing f. It reports false
+// if the binary was built without absolute source paths, e.g. with -trimpath.
+func (f *Function) Source() (file string, line int, ok bool) {
+	if f.Call == nil {
+		return "", 0, false
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(f.Call).Pointer())
+	if fn == nil {
+		return "", 0, false
+	}
+	file, line = fn.FileLine(fn.Entry())
+	return file, line, filepath.IsAbs(file)
+}
+
+// ResultType returns the type of applying f to an argument of type arg.
+func (f *Function) ResultType(arg types.Type) types.Type {
+	if arg.Overlaps(types.NULL) && !f.Accepts.Has(types.NULL) {
+		return f.Returns | types.NULL
+	}
+	return f.Returns
+}
+
+// Registry holds the functions available to templates.
+type Registry struct {
+	mu  sync.RWMutex
+	fns map[string]*Function
+}
+
+func NewRegistry(fns ...*Function) *Registry {
+	r := &Registry{
+		fns: map[string]*Function{},
+	}
+	for _, f := range fns {
+		r.Register(f)
+	}
+	return r
+}
+
+// Register adds f to the registry, replacing any function with the same name.
+func (r *Registry) Register(f *Function) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fns[f.Name] = f
+}
+
+func (r *Registry) Lookup(name string) (*Function, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.fns[name]
+	return f, ok
+}
+
+// Call applies the function name to arg.
+func (r *Registry) Call(name string, arg any) (any, error) {
+	f, ok := r.Lookup(name)
+	if !ok {
+		return nil, newUnknownFunctionError(name, r.Suggest(name))
+	}
+	return f.Apply(arg)
+}
+
+// Suggest returns the names of registered functions name is likely a
+// misspelling of, closest first.
+func (r *Registry) Suggest(name string) []string {
+	return suggest.Closest(name, r.Names())
+}
+
+// Names returns the names of all registered functions in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.fns))
+	for name := range r.fns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package functions
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	tt := []struct {
+		name     string
+		arg      any
+		expected any
+		err      string
+	}{
+		{"toUpper", "abc", "ABC", ""},
+		{"toLower", "ABC", "abc", ""},
+		{"trim", "  a b ", "a b", ""},
+		{"length", "äbc", float64(3), ""},
+		{"length", []any{1.0, 2.0}, float64(2), ""},
+		{"toString", 1.5, "1.5", ""},
+		{"toString", map[string]any{"a": true}, `{"a":true}`, ""},
+		{"toNumber", " 42 ", float64(42), ""},
+		{"toNumber", "x", nil, `toNumber cannot convert "x"`},
+		{"fromJson", `[1]`, []any{1.0}, ""},
+		{"keys", map[string]any{"b": 1.0, "a": 2.0}, []any{"a", "b"}, ""},
+		{"toUpper", nil, nil, ""},
+		{"toUpper", []any{}, nil, "toUpper expects string, got array"},
+		{"toupper", "a", nil, "unknown function toupper, did you mean toUpper?"},
+	}
+
+	for _, test := range tt {
+		actual, err := Default.Call(test.name, test.arg)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s(%v): wrong error, expected=%s got=%v", test.name, test.arg, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s(%v): unexpected error %s", test.name, test.arg, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s(%v): wrong result, expected=%v got=%v", test.name, test.arg, test.expected, actual)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	f, _ := Default.Lookup("toUpper")
+	file, line, ok := f.Source()
+	if !ok || filepath.Base(file) != "builtin.go" || line == 0 {
+		t.Fatalf("wrong source, got=%s:%d %v", file, line, ok)
+	}
+	if _, _, ok := (&Function{Name: "nil"}).Source(); ok {
+		t.Fatal("expected no source for a function without Call")
+	}
+}
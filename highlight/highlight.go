@@ -0,0 +1,130 @@
+package highlight
+
+import (
+	"html"
+	"os"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Class is the highlighting category of a token.
+type Class int
+
+const (
+	PLAIN Class = iota
+	DELIMITER
+	IDENTIFIER
+	STRING
+	OPERATOR
+	PIPE
+	ILLEGAL
+)
+
+// ClassOf returns the class of tok.
+func ClassOf(tok tokenizer.Token) Class {
+	switch tok.T {
+	case tokenizer.WILDCARD_OPEN, tokenizer.WILDCARD_CLOSE,
+		tokenizer.LBRACKET, tokenizer.RBRACKET,
+		tokenizer.LPAREN, tokenizer.RPAREN,
+		tokenizer.LBRACE, tokenizer.RBRACE:
+		return DELIMITER
+	case tokenizer.TEXT:
+		if tok.Quoted {
+			return STRING
+		}
+		return IDENTIFIER
+	case tokenizer.DOT, tokenizer.NULL_COALESCE, tokenizer.QUESTION_MARK:
+		return OPERATOR
+	case tokenizer.PIPE:
+		return PIPE
+	case tokenizer.ILLEGAL:
+		return ILLEGAL
+	}
+	return PLAIN
+}
+
+// ANSI_COLORS holds the SGR parameters of every class.
+var ANSI_COLORS = map[Class]string{
+	DELIMITER:  "35",
+	IDENTIFIER: "36",
+	STRING:     "32",
+	OPERATOR:   "33",
+	PIPE:       "34",
+	ILLEGAL:    "1;31",
+}
+
+// HTML_CLASSES holds the CSS class of every class, styled by CSS.
+var HTML_CLASSES = map[Class]string{
+	DELIMITER:  "wc-delimiter",
+	IDENTIFIER: "wc-identifier",
+	STRING:     "wc-string",
+	OPERATOR:   "wc-operator",
+	PIPE:       "wc-pipe",
+	ILLEGAL:    "wc-illegal",
+}
+
+// CSS is a default stylesheet for HTML.
+const CSS = `.wc-delimiter { color: #a626a4; }
+.wc-identifier { color: #0184bc; }
+.wc-string { color: #50a14f; }
+.wc-operator { color: #c18401; }
+.wc-pipe { color: #4078f2; }
+.wc-illegal { color: #e45649; font-weight: bold; text-decoration: underline wavy; }
+`
+
+// ANSI returns src colored with ANSI escape sequences.
+func ANSI(src string) string {
+	var out strings.Builder
+	each(src, func(text string, c Class) {
+		color, ok := ANSI_COLORS[c]
+		if !ok {
+			out.WriteString(text)
+			return
+		}
+		out.WriteString("\x1b[" + color + "m" + text + "\x1b[0m")
+	})
+	return out.String()
+}
+
+// HTML returns src escaped for HTML with every token in a span of its
+// HTML_CLASSES class, meant to be placed in a <pre> or <code> element.
+func HTML(src string) string {
+	var out strings.Builder
+	each(src, func(text string, c Class) {
+		class, ok := HTML_CLASSES[c]
+		if !ok {
+			out.WriteString(html.EscapeString(text))
+			return
+		}
+		out.WriteString(`<span class="` + class + `">` + html.EscapeString(text) + "</span>")
+	})
+	return out.String()
+}
+
+// each calls f for every token of src and for the text between them, which
+// is PLAIN, so that the texts add up to src.
+func each(src string, f func(text string, c Class)) {
+	pos := 0
+	t := tokenizer.New(src)
+	for tok := t.Next(); tok.T != tokenizer.EOF; tok = t.Next() {
+		if tok.Start > pos {
+			f(src[pos:tok.Start], PLAIN)
+		}
+		f(src[tok.Start:tok.End], ClassOf(tok))
+		pos = tok.End
+	}
+	if pos < len(src) {
+		f(src[pos:], PLAIN)
+	}
+}
+
+// Enabled reports whether colors should be written to f: if f is a terminal
+// and the NO_COLOR environment variable is not set.
+func Enabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
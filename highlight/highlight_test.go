@@ -0,0 +1,56 @@
+package highlight
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestANSI(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"{{ a.b }}",
+			"\x1b[35m{{\x1b[0m \x1b[36ma\x1b[0m\x1b[33m.\x1b[0m\x1b[36mb\x1b[0m \x1b[35m}}\x1b[0m",
+		},
+		{
+			`{{ x ?? "y" | f }}`,
+			"\x1b[35m{{\x1b[0m \x1b[36mx\x1b[0m \x1b[33m??\x1b[0m \x1b[32m\"y\"\x1b[0m \x1b[34m|\x1b[0m \x1b[36mf\x1b[0m \x1b[35m}}\x1b[0m",
+		},
+		{"{{ a ! }}", "\x1b[35m{{\x1b[0m \x1b[36ma\x1b[0m \x1b[1;31m!\x1b[0m \x1b[35m}}\x1b[0m"},
+	}
+
+	ansi := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	for _, tc := range tt {
+		got := ANSI(tc.input)
+		if got != tc.expected {
+			t.Fatalf("%s: expected=%q got=%q", tc.input, tc.expected, got)
+		}
+		if plain := ansi.ReplaceAllString(got, ""); plain != tc.input {
+			t.Fatalf("%s: text changed to %q", tc.input, plain)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	input := `{{ a ?? '<b>' }}`
+	expected := `<span class="wc-delimiter">{{</span> <span class="wc-identifier">a</span> ` +
+		`<span class="wc-operator">??</span> <span class="wc-string">&#39;&lt;b&gt;&#39;</span> ` +
+		`<span class="wc-delimiter">}}</span>`
+	if got := HTML(input); got != expected {
+		t.Fatalf("expected=%s got=%s", expected, got)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if Enabled(f) {
+		t.Fatal("colors enabled for a regular file")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/batch"
+)
+
+const STDIN = "<stdin>"
+
+// input is a template given to a command.
+type input struct {
+	batch.Item
+	// File is set if the template was read from a file that may be rewritten.
+	File bool
+}
+
+// inputFlags are the flags of commands reading templates.
+type inputFlags struct {
+	exprs multiFlag
+}
+
+func addInputFlags(fs *flag.FlagSet) *inputFlags {
+	in := &inputFlags{}
+	fs.Var(&in.exprs, "e", "`template` given on the command line, may be repeated")
+	return in
+}
+
+// read returns the -e templates followed by those of args, which are files,
+// globs or - for stdin. Stdin is read if neither -e nor args are given.
+func (in *inputFlags) read(args []string) ([]input, error) {
+	var inputs []input
+	for _, e := range in.exprs {
+		inputs = append(inputs, input{Item: batch.Item{Name: "-e", Source: e}})
+	}
+	if len(in.exprs) == 0 && len(args) == 0 {
+		args = []string{"-"}
+	}
+	for _, arg := range args {
+		if arg == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, input{Item: batch.Item{Name: STDIN, Source: string(data)}})
+			continue
+		}
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			paths, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(paths) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+		}
+		for _, item := range batch.FromFiles(paths) {
+			if item.Err != nil {
+				return nil, item.Err
+			}
+			inputs = append(inputs, input{Item: item, File: true})
+		}
+	}
+	return inputs, nil
+}
+
+// header separates the output of several inputs by printing the name of the
+// i-th input before its output.
+func header(w io.Writer, inputs []input, i int) {
+	if len(inputs) < 2 {
+		return
+	}
+	if i > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "==> %s <==\n", inputs[i].Name)
+}
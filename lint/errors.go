@@ -0,0 +1,16 @@
+package lint
+
+import "fmt"
+
+const (
+	UNKNOWN_RULE     = "unknown lint rule %s"
+	UNKNOWN_SEVERITY = "unknown severity %s"
+)
+
+func newUnknownRuleError(id string) error {
+	return fmt.Errorf(UNKNOWN_RULE, id)
+}
+
+func newUnknownSeverityError(s string) error {
+	return fmt.Errorf(UNKNOWN_SEVERITY, s)
+}
@@ -0,0 +1,127 @@
+package lint
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Fix returns src, a template, with the first safe fix of every diagnostic
+// applied. A fix is applied whole or not at all: it is skipped if its edits
+// overlap those of an earlier fix or if the result does not parse to the trees
+// it intends.
+func Fix(src string, diags []diag.Diagnostic) string {
+	tmpl, err := parser.ParseTemplate(src)
+	if err != nil {
+		return src
+	}
+	var applied []diag.Edit
+	// subs maps the spans of replaced nodes to the expressions replacing them
+	subs := map[parser.Span]parser.Expression{}
+	for _, d := range diags {
+		i := slices.IndexFunc(d.Fixes, func(f diag.Fix) bool { return !f.Unsafe })
+		if i < 0 {
+			continue
+		}
+		edits := append(applied[:len(applied):len(applied)], d.Fixes[i].Edits...)
+		if overlapping(edits) {
+			continue
+		}
+		next, ok := substitutions(tmpl.Wildcards, d.Fixes[i].Edits, subs)
+		if !ok {
+			continue
+		}
+		fixed, err := parser.ParseTemplate(diag.Apply(src, edits))
+		if err != nil || !matchesAll(tmpl.Wildcards, fixed.Wildcards, next) {
+			continue
+		}
+		applied, subs = edits, next
+	}
+	return diag.Apply(src, applied)
+}
+
+// overlapping reports whether any two edits overlap, which diag.Apply would
+// resolve by dropping one of them.
+func overlapping(edits []diag.Edit) bool {
+	sorted := make([]diag.Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Span.Start < sorted[j].Span.Start
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Span.Start < sorted[i-1].Span.End {
+			return true
+		}
+	}
+	return false
+}
+
+// substitutions returns subs extended with the edits replacing a whole node of
+// one of roots, reporting false if the new text of one does not parse. Other
+// edits, e.g. of parentheses, are meant to leave the trees unchanged.
+func substitutions(roots []*parser.Wildcard, edits []diag.Edit, subs map[parser.Span]parser.Expression) (map[parser.Span]parser.Expression, bool) {
+	next := maps.Clone(subs)
+	for _, e := range edits {
+		if !slices.ContainsFunc(roots, func(root *parser.Wildcard) bool { return hasNode(root, e.Span) }) {
+			continue
+		}
+		ast, err := parser.New(tokenizer.New("{{ " + e.NewText + " }}")).Parse()
+		if err != nil {
+			return nil, false
+		}
+		next[e.Span] = ast.Root.Expression
+	}
+	return next, true
+}
+
+func hasNode(root parser.Expression, span parser.Span) bool {
+	found := false
+	parser.Inspect(root, func(e parser.Expression) bool {
+		found = found || e.Pos() == span
+		return !found
+	})
+	return found
+}
+
+// matchesAll reports whether fixed are the wildcards orig, one for one, with
+// the nodes at the spans of subs replaced.
+func matchesAll(orig, fixed []*parser.Wildcard, subs map[parser.Span]parser.Expression) bool {
+	if len(orig) != len(fixed) {
+		return false
+	}
+	for i := range orig {
+		if !matches(orig[i], fixed[i], subs) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether fixed is the tree orig with the nodes at the spans
+// of subs replaced.
+func matches(orig, fixed parser.Expression, subs map[parser.Span]parser.Expression) bool {
+	if orig == nil || fixed == nil {
+		return orig == nil && fixed == nil
+	}
+	if want, ok := subs[orig.Pos()]; ok {
+		return parser.Equal(want, fixed, parser.IgnoreSpans())
+	}
+	if orig.Type() != fixed.Type() {
+		return false
+	}
+	if l, ok := orig.(*parser.Literal); ok {
+		r := fixed.(*parser.Literal)
+		return l.V == r.V && l.Quoted == r.Quoted
+	}
+	oc, fc := parser.Children(orig), parser.Children(fixed)
+	for i := range oc {
+		if !matches(oc[i], fc[i], subs) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package lint
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Rule is a single lint check identified by ID.
+type Rule struct {
+	ID       string
+	Doc      string
+	Severity diag.Severity
+	Check    func(c *Context)
+}
+
+// Context is passed to a Rule while checking one template.
+type Context struct {
+	Source    string
+	Root      *parser.Wildcard
+	Tokens    []tokenizer.Token
+	Functions *functions.Registry
+
+	rule     *Rule
+	severity diag.Severity
+	options  map[string]any
+	diags    *[]diag.Diagnostic
+}
+
+// Report adds a diagnostic for the current rule.
+func (c *Context) Report(span parser.Span, message string, fixes ...diag.Fix) {
+	*c.diags = append(*c.diags, diag.Diagnostic{
+		Span:     span,
+		Severity: c.severity,
+		Code:     c.rule.ID,
+		Message:  message,
+		Fixes:    fixes,
+	})
+}
+
+// Note adds a note to the diagnostic reported last.
+func (c *Context) Note(span parser.Span, message string) {
+	if n := len(*c.diags); n > 0 {
+		d := &(*c.diags)[n-1]
+		d.Notes = append(d.Notes, diag.Note{Span: span, Message: message})
+	}
+}
+
+// Text returns the source of span.
+func (c *Context) Text(span parser.Span) string {
+	return c.Source[span.Start:span.End]
+}
+
+// IntOption returns the configured option name of the current rule, or def.
+func (c *Context) IntOption(name string, def int) int {
+	switch v := c.options[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return def
+}
+
+// RuleConfig overrides the defaults of a rule.
+type RuleConfig struct {
+	Disabled bool           `json:"disabled"`
+	Severity string         `json:"severity"`
+	Options  map[string]any `json:"options"`
+}
+
+// Config selects and configures the rules of a Linter.
+type Config struct {
+	Rules map[string]RuleConfig `json:"rules"`
+}
+
+// LoadConfig reads a JSON configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	for id, rc := range cfg.Rules {
+		if _, ok := ruleByID(id); !ok {
+			return nil, newUnknownRuleError(id)
+		}
+		if _, err := parseSeverity(rc.Severity); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+type Linter struct {
+	config    *Config
+	functions *functions.Registry
+}
+
+// New returns a Linter running every rule, configured by cfg. Functions are
+// resolved with fns; cfg and fns may be nil.
+func New(cfg *Config, fns *functions.Registry) *Linter {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	return &Linter{
+		config:    cfg,
+		functions: fns,
+	}
+}
+
+// Lint parses src and returns the diagnostics of all enabled rules ordered by
+// position. Syntax errors are returned as err.
+func (l *Linter) Lint(src string) ([]diag.Diagnostic, error) {
+	ast, err := parser.New(tokenizer.New(src)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return l.Check(src, ast.Root), nil
+}
+
+// CheckTemplate runs all enabled rules on each wildcard of tmpl, reporting
+// spans in tmpl.Source.
+func (l *Linter) CheckTemplate(tmpl parser.Template) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	for _, w := range tmpl.Wildcards {
+		// rules reparse the source, so each wildcard is linted on its own
+		span := w.Pos()
+		lints, err := l.Lint(tmpl.Source[span.Start:span.End])
+		if err != nil {
+			continue
+		}
+		for _, d := range lints {
+			diags = append(diags, diag.Shift(d, span.Start))
+		}
+	}
+	return diags
+}
+
+// Check runs all enabled rules on root, the parsed tree of src.
+func (l *Linter) Check(src string, root *parser.Wildcard) []diag.Diagnostic {
+	var tokens []tokenizer.Token
+	t := tokenizer.New(src)
+	for tok := t.Next(); tok.T != tokenizer.EOF; tok = t.Next() {
+		tokens = append(tokens, tok)
+	}
+
+	var diags []diag.Diagnostic
+	for _, rule := range Rules {
+		rc := l.config.Rules[rule.ID]
+		if rc.Disabled {
+			continue
+		}
+		severity := rule.Severity
+		if rc.Severity != "" {
+			severity, _ = parseSeverity(rc.Severity)
+		}
+		rule.Check(&Context{
+			Source:    src,
+			Root:      root,
+			Tokens:    tokens,
+			Functions: l.functions,
+			rule:      rule,
+			severity:  severity,
+			options:   rc.Options,
+			diags:     &diags,
+		})
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Span.Start < diags[j].Span.Start
+	})
+	return diags
+}
+
+func ruleByID(id string) (*Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func parseSeverity(s string) (diag.Severity, error) {
+	switch s {
+	case "", "error":
+		return diag.ERROR, nil
+	case "warning":
+		return diag.WARNING, nil
+	case "info":
+		return diag.INFO, nil
+	}
+	return 0, newUnknownSeverityError(s)
+}
@@ -0,0 +1,179 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+func TestLint(t *testing.T) {
+	tt := []struct {
+		input    string
+		config   string
+		expected []string
+		fixed    string
+	}{
+		{`{{ a.b ?? "x" | toUpper }}`, "", nil, `{{ a.b ?? "x" | toUpper }}`},
+		{`{{ "a" ?? b }}`, "", []string{
+			"10:11: warning: fallback `b` is unreachable, `\"a\"` is never null [unreachable-fallback]",
+		}, `{{ "a" }}`},
+		{`{{ (a.b) | toUpper }}`, "", []string{
+			"3:8: info: redundant parentheses [redundant-parens]",
+		}, `{{ a.b | toUpper }}`},
+		{`{{ a ?? (b | toUpper) }}`, "", nil, `{{ a ?? (b | toUpper) }}`},
+		{`{{ a.{{b.{{c.{{d}}}}}} }}`, "", []string{
+			"13:18: warning: wildcard nested 4 deep, the maximum is 3 [max-depth]",
+		}, `{{ a.{{b.{{c.{{d}}}}}} }}`},
+		{`{{ a.{{b.{{c}}}} }}`, `{"rules": {"max-depth": {"severity": "error", "options": {"max": 2}}}}`, []string{
+			"9:14: error: wildcard nested 3 deep, the maximum is 2 [max-depth]",
+		}, `{{ a.{{b.{{c}}}} }}`},
+		{`{{ a["b"].'c'["d e"] }}`, "", []string{
+			"5:8: info: key \"b\" can be written bare [quoted-key]",
+			"10:13: info: key 'c' can be written bare [quoted-key]",
+		}, `{{ a[b].c["d e"] }}`},
+		{`{{ a["b"] }}`, `{"rules": {"quoted-key": {"disabled": true}}}`, nil, `{{ a["b"] }}`},
+		{`{{ a | toupper }}`, "", []string{
+			"7:14: error: unknown function toupper, did you mean toUpper? [unknown-function]",
+		}, `{{ a | toupper }}`},
+		{`{{ a ?? b ?? a.c ?? b }}`, "", []string{
+			"20:21: warning: duplicate operand `b` in ?? [duplicate-coalesce]",
+		}, `{{ a ?? b ?? a.c }}`},
+		{`{{ ("a") ?? b }}`, `{"rules": {"redundant-parens": {"disabled": true}}}`, []string{
+			"12:13: warning: fallback `b` is unreachable, `\"a\"` is never null [unreachable-fallback]",
+		}, `{{ "a" }}`},
+		{`{{ (a ?? b) ?? b }}`, `{"rules": {"redundant-parens": {"disabled": true}}}`, []string{
+			"15:16: warning: duplicate operand `b` in ?? [duplicate-coalesce]",
+		}, `{{ a ?? b }}`},
+		{`{{ a.("b") }}`, `{"rules": {"redundant-parens": {"disabled": true}}}`, []string{
+			"6:9: info: key \"b\" can be written bare [quoted-key]",
+		}, `{{ a.(b) }}`},
+		{`{{ (a ?? b) ?? b }}`, "", []string{
+			"3:11: info: redundant parentheses [redundant-parens]",
+			"15:16: warning: duplicate operand `b` in ?? [duplicate-coalesce]",
+		}, `{{ a ?? b ?? b }}`},
+	}
+
+	for i, test := range tt {
+		t.Logf("lint-%d %s", i, test.input)
+		var cfg *Config
+		if test.config != "" {
+			var err error
+			cfg, err = ParseConfig([]byte(test.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		diags, err := New(cfg, functions.Default).Lint(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diags) != len(test.expected) {
+			t.Fatalf("wrong number of diagnostics, expected=%v got=%v", test.expected, diags)
+		}
+		for j, d := range diags {
+			if d.String() != test.expected[j] {
+				t.Errorf("wrong diagnostic, expected=%s got=%s", test.expected[j], d.String())
+			}
+		}
+		if fixed := Fix(test.input, diags); fixed != test.fixed {
+			t.Errorf("wrong fix, expected=%s got=%s", test.fixed, fixed)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	replace := func(start, end int, text string) diag.Edit {
+		return diag.Edit{Span: parser.Span{Start: start, End: end}, NewText: text}
+	}
+	tt := []struct {
+		input    string
+		fixes    [][]diag.Edit
+		expected string
+	}{
+		{`{{ a.b.c }}`, [][]diag.Edit{{replace(3, 4, "x")}, {replace(7, 8, "z")}}, `{{ x.b.z }}`},
+		// the second fix overlaps the first and is skipped whole
+		{`{{ a.b.c }}`, [][]diag.Edit{{replace(3, 4, "x")}, {replace(7, 8, "z"), replace(3, 6, "y")}}, `{{ x.b.c }}`},
+		{`{{ a.b.c }}`, [][]diag.Edit{{replace(3, 6, "y"), replace(4, 5, "")}}, `{{ a.b.c }}`},
+		// the result does not parse
+		{`{{ a ?? b }}`, [][]diag.Edit{{replace(8, 9, "b ??")}}, `{{ a ?? b }}`},
+		// the replacement binds differently in place
+		{`{{ a ?? b | f }}`, [][]diag.Edit{{replace(8, 9, "b | g")}}, `{{ a ?? b | f }}`},
+		{`{{ a ?? b | f }}`, [][]diag.Edit{{replace(8, 9, "(b | g)")}}, `{{ a ?? (b | g) | f }}`},
+		// edits not replacing a node must leave the tree unchanged
+		{`{{ (a.b) }}`, [][]diag.Edit{{replace(3, 4, ""), replace(7, 8, "")}}, `{{ a.b }}`},
+		{`{{ a ?? (b ?? c) }}`, [][]diag.Edit{{replace(8, 9, ""), replace(15, 16, "")}}, `{{ a ?? (b ?? c) }}`},
+		// every wildcard of a template is fixed in place
+		{"x {{ a }} y {{ (b) }}\n", [][]diag.Edit{{replace(5, 6, "c")}, {replace(15, 16, ""), replace(17, 18, "")}}, "x {{ c }} y {{ b }}\n"},
+		// the result has another wildcard
+		{`{{ a }}`, [][]diag.Edit{{replace(3, 4, "a }} {{ a")}}, `{{ a }}`},
+	}
+
+	for _, test := range tt {
+		var diags []diag.Diagnostic
+		for _, edits := range test.fixes {
+			diags = append(diags, diag.Diagnostic{Fixes: []diag.Fix{{Edits: edits}}})
+		}
+		if fixed := Fix(test.input, diags); fixed != test.expected {
+			t.Errorf("%s: wrong fix, expected=%s got=%s", test.input, test.expected, fixed)
+		}
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	tmpl, err := parser.ParseTemplate("a {{ (b) }} c\n{{ d ?? d }}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"5:8: info: redundant parentheses [redundant-parens]",
+		"22:23: warning: duplicate operand `d` in ?? [duplicate-coalesce]",
+	}
+	diags := New(nil, functions.Default).CheckTemplate(tmpl)
+	if len(diags) != len(expected) {
+		t.Fatalf("wrong number of diagnostics, expected=%v got=%v", expected, diags)
+	}
+	for i, d := range diags {
+		if d.String() != expected[i] {
+			t.Errorf("wrong diagnostic, expected=%s got=%s", expected[i], d.String())
+		}
+	}
+	if fixed := Fix(tmpl.Source, diags); fixed != "a {{ b }} c\n{{ d }}\n" {
+		t.Errorf("wrong fix, got=%q", fixed)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tt := []struct {
+		input string
+		err   string
+	}{
+		{`{"rules": {"max-depth": {"options": {"max": 5}}}}`, ""},
+		{`{"rules": {"no-such-rule": {}}}`, "unknown lint rule no-such-rule"},
+		{`{"rules": {"max-depth": {"severity": "fatal"}}}`, "unknown severity fatal"},
+	}
+
+	for _, test := range tt {
+		_, err := ParseConfig([]byte(test.input))
+		if test.err == "" && err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("wrong error, expected=%s got=%v", test.err, err)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	diags, err := New(nil, functions.Default).Lint(`{{ a ?? b ?? a.c ?? b }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || len(diags[0].Notes) != 1 {
+		t.Fatalf("expected one diagnostic with a note, got %+v", diags)
+	}
+	if n := diags[0].Notes[0]; n.Span.Start != 8 || n.Span.End != 9 || n.Message != "first used here" {
+		t.Fatalf("wrong note %+v", n)
+	}
+}
@@ -0,0 +1,268 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+const (
+	UNREACHABLE_FALLBACK = "unreachable-fallback"
+	REDUNDANT_PARENS     = "redundant-parens"
+	MAX_DEPTH            = "max-depth"
+	QUOTED_KEY           = "quoted-key"
+	UNKNOWN_FUNCTION     = "unknown-function"
+	DUPLICATE_COALESCE   = "duplicate-coalesce"
+)
+
+// DEFAULT_MAX_DEPTH is the default of the max-depth option "max".
+const DEFAULT_MAX_DEPTH = 3
+
+// Rules holds every available rule in the order they are run.
+var Rules = []*Rule{
+	{
+		ID:       UNREACHABLE_FALLBACK,
+		Doc:      "the fallback of a ?? whose primary is a constant is never used",
+		Severity: diag.WARNING,
+		Check:    checkUnreachableFallback,
+	},
+	{
+		ID:       REDUNDANT_PARENS,
+		Doc:      "parentheses that do not change the parsed tree",
+		Severity: diag.INFO,
+		Check:    checkRedundantParens,
+	},
+	{
+		ID:       MAX_DEPTH,
+		Doc:      "wildcards nested deeper than the option max",
+		Severity: diag.WARNING,
+		Check:    checkMaxDepth,
+	},
+	{
+		ID:       QUOTED_KEY,
+		Doc:      "quoted keys that could be written bare",
+		Severity: diag.INFO,
+		Check:    checkQuotedKey,
+	},
+	{
+		ID:       UNKNOWN_FUNCTION,
+		Doc:      "functions after | that are not registered",
+		Severity: diag.ERROR,
+		Check:    checkUnknownFunction,
+	},
+	{
+		ID:       DUPLICATE_COALESCE,
+		Doc:      "operands repeated in a chain of ??",
+		Severity: diag.WARNING,
+		Check:    checkDuplicateCoalesce,
+	},
+}
+
+func checkUnreachableFallback(c *Context) {
+	parser.Inspect(c.Root, func(e parser.Expression) bool {
+		n, ok := e.(*parser.NullCoalesceExpression)
+		if !ok || !isConstant(n.Primary) {
+			return true
+		}
+		c.Report(n.Fallback.Pos(),
+			fmt.Sprintf("fallback `%s` is unreachable, `%s` is never null", c.Text(n.Fallback.Pos()), c.Text(n.Primary.Pos())),
+			replaceWith(c, "remove the fallback", n, n.Primary)...)
+		return true
+	})
+}
+
+func checkRedundantParens(c *Context) {
+	for i, open := range c.Tokens {
+		if open.T != tokenizer.LPAREN {
+			continue
+		}
+		j := matchingParen(c.Tokens, i)
+		if j < 0 {
+			continue
+		}
+		close := c.Tokens[j]
+		stripped := c.Source[:open.Start] + c.Source[open.End:close.Start] + c.Source[close.End:]
+		ast, err := parser.New(tokenizer.New(stripped)).Parse()
+		if err != nil || !parser.Equal(c.Root, ast.Root, parser.IgnoreSpans()) {
+			continue
+		}
+		c.Report(parser.Span{Start: open.Start, End: close.End}, "redundant parentheses", diag.Fix{
+			Message: "remove the parentheses",
+			Edits: []diag.Edit{
+				{Span: parser.Span{Start: open.Start, End: open.End}},
+				{Span: parser.Span{Start: close.Start, End: close.End}},
+			},
+		})
+	}
+}
+
+func checkMaxDepth(c *Context) {
+	max := c.IntOption("max", DEFAULT_MAX_DEPTH)
+	var walk func(e parser.Expression, depth int)
+	walk = func(e parser.Expression, depth int) {
+		if _, ok := e.(*parser.Wildcard); ok {
+			depth++
+			if depth > max {
+				c.Report(e.Pos(), fmt.Sprintf("wildcard nested %d deep, the maximum is %d", depth, max))
+				return
+			}
+		}
+		for _, child := range parser.Children(e) {
+			walk(child, depth)
+		}
+	}
+	walk(c.Root, 0)
+}
+
+func checkQuotedKey(c *Context) {
+	parser.Inspect(c.Root, func(e parser.Expression) bool {
+		var key parser.Expression
+		switch v := e.(type) {
+		case *parser.DotExpression:
+			key = v.Key
+		case *parser.IndexExpression:
+			key = v.Key
+		default:
+			return true
+		}
+		l, ok := key.(*parser.Literal)
+		if !ok || !l.Quoted || !isBare(l.V) {
+			return true
+		}
+		c.Report(l.Span, fmt.Sprintf("key %s can be written bare", c.Text(l.Span)), diag.Fix{
+			Message: "remove the quotes",
+			Edits:   []diag.Edit{{Span: l.Span, NewText: l.V}},
+		})
+		return true
+	})
+}
+
+func checkUnknownFunction(c *Context) {
+	if c.Functions == nil {
+		return
+	}
+	parser.Inspect(c.Root, func(e parser.Expression) bool {
+		f, ok := e.(*parser.FunctionExpression)
+		if !ok {
+			return true
+		}
+		if name, ok := f.Name.(*parser.Literal); ok {
+			if _, ok := c.Functions.Lookup(name.V); !ok {
+				suggestions := c.Functions.Suggest(name.V)
+				c.Report(name.Span, fmt.Sprintf("unknown function %s%s", name.V, suggest.DidYouMean(suggestions, nil)),
+					diag.Replacements(name.Span, suggestions)...)
+			}
+		}
+		return true
+	})
+}
+
+// operand is an operand of a chain of ?? and the node it belongs to.
+type operand struct {
+	e      parser.Expression
+	parent *parser.NullCoalesceExpression
+}
+
+func checkDuplicateCoalesce(c *Context) {
+	seen := map[*parser.NullCoalesceExpression]bool{}
+	parser.Inspect(c.Root, func(e parser.Expression) bool {
+		n, ok := e.(*parser.NullCoalesceExpression)
+		if !ok || seen[n] {
+			return true
+		}
+		ops := flattenCoalesce(n, nil, seen)
+		for j := range ops {
+			for i := 0; i < j; i++ {
+				if !parser.Equal(ops[i].e, ops[j].e, parser.IgnoreSpans()) {
+					continue
+				}
+				dup := ops[j]
+				other := dup.parent.Primary
+				if other == dup.e {
+					other = dup.parent.Fallback
+				}
+				c.Report(dup.e.Pos(),
+					fmt.Sprintf("duplicate operand `%s` in ??", c.Text(dup.e.Pos())),
+					replaceWith(c, "remove the duplicate", dup.parent, other)...)
+				c.Note(ops[i].e.Pos(), "first used here")
+				break
+			}
+		}
+		return true
+	})
+}
+
+func flattenCoalesce(e parser.Expression, parent *parser.NullCoalesceExpression, seen map[*parser.NullCoalesceExpression]bool) []operand {
+	n, ok := e.(*parser.NullCoalesceExpression)
+	if !ok {
+		return []operand{{e: e, parent: parent}}
+	}
+	seen[n] = true
+	return append(flattenCoalesce(n.Primary, n, seen), flattenCoalesce(n.Fallback, n, seen)...)
+}
+
+// replaceWith returns a fix replacing node with the source of child, or no
+// fixes if the child binds looser than node and could parse differently in its
+// place.
+func replaceWith(c *Context, message string, node, child parser.Expression) []diag.Fix {
+	switch child.(type) {
+	case *parser.Literal, *parser.Wildcard, *parser.DotExpression, *parser.IndexExpression:
+	default:
+		if child.Type() != node.Type() {
+			return nil
+		}
+	}
+	return []diag.Fix{{
+		Message: message,
+		Edits:   []diag.Edit{{Span: node.Pos(), NewText: c.Text(child.Pos())}},
+	}}
+}
+
+func isConstant(e parser.Expression) bool {
+	switch v := e.(type) {
+	case *parser.Literal:
+		return v.Quoted
+	case *parser.Wildcard:
+		return isConstant(v.Expression)
+	}
+	return false
+}
+
+// isBare reports whether s would be read as a single TEXT token without quotes.
+func isBare(s string) bool {
+	if s == "" {
+		return false
+	}
+	t := tokenizer.New(s)
+	tok := t.Next()
+	return tok.T == tokenizer.TEXT && !tok.Quoted && tok.Literal == s && t.Next().T == tokenizer.EOF
+}
+
+func matchingParen(tokens []tokenizer.Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].T {
+		case tokenizer.LPAREN:
+			depth++
+		case tokenizer.RPAREN:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Describe returns the ID, severity and doc of every rule, one per line.
+func Describe() string {
+	var out strings.Builder
+	for _, r := range Rules {
+		fmt.Fprintf(&out, "%-22s %-8s %s\n", r.ID, r.Severity, r.Doc)
+	}
+	return out.String()
+}
@@ -0,0 +1,31 @@
+package lsp
+
+import (
+	"fmt"
+
+	"github.com/jorgepbrown/wildcard-tree/complete"
+)
+
+// completion offers the candidates of complete.Complete, keeping their order.
+func (s *Server) completion(p TextDocumentPositionParams) (any, error) {
+	list := CompletionList{Items: []CompletionItem{}}
+	d, offset := s.at(p)
+	if d == nil {
+		return list, nil
+	}
+	for i, c := range complete.Complete(d.text, offset, s.schema, s.functions) {
+		kind := COMPLETION_FIELD
+		if c.Kind == complete.FUNCTION_KIND {
+			kind = COMPLETION_FUNCTION
+		}
+		list.Items = append(list.Items, CompletionItem{
+			Label:         c.Label,
+			Kind:          kind,
+			Detail:        c.Detail,
+			Documentation: c.Doc,
+			SortText:      fmt.Sprintf("%04d", i),
+			TextEdit:      &TextEdit{Range: d.rangeOf(c.Span), NewText: c.Text},
+		})
+	}
+	return list, nil
+}
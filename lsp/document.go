@@ -0,0 +1,121 @@
+package lsp
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// document is an open text document: a template of text and wildcards. It is
+// parsed with parser.ParseTemplateAll, which keeps going after syntax errors,
+// so every wildcard that parses can be checked while the user types.
+type document struct {
+	uri       string
+	version   int
+	text      string
+	lines     []int // offset of the start of every line
+	wildcards []*parser.Wildcard
+	errors    []diag.Diagnostic
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{
+		uri:     uri,
+		version: version,
+		text:    text,
+		lines:   []int{0},
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.parse()
+	return d
+}
+
+func (d *document) parse() {
+	tmpl, errs := parser.ParseTemplateAll(d.text)
+	d.wildcards = tmpl.Wildcards
+	for _, err := range errs {
+		if e, ok := diag.FromError(err); ok {
+			d.errors = append(d.errors, e)
+		}
+	}
+}
+
+// wildcard returns the top level wildcard containing offset, which may be at
+// its end.
+func (d *document) wildcard(offset int) *parser.Wildcard {
+	for _, w := range d.wildcards {
+		if w.Start <= offset && offset <= w.End {
+			return w
+		}
+	}
+	return nil
+}
+
+// position returns the LSP position of the byte offset.
+func (d *document) position(offset int) Position {
+	offset = max(0, min(offset, len(d.text)))
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	return Position{Line: line, Character: utf16Len(d.text[d.lines[line]:offset])}
+}
+
+func (d *document) rangeOf(span parser.Span) Range {
+	return Range{Start: d.position(span.Start), End: d.position(span.End)}
+}
+
+// offset returns the byte offset of the LSP position p, clamped to the line.
+func (d *document) offset(p Position) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[p.Line]
+	for units := 0; offset < len(d.text) && d.text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		units += utf16RuneLen(r)
+		if units > p.Character {
+			break
+		}
+		offset += size
+	}
+	return offset
+}
+
+// utf16Len returns the length of s in UTF-16 code units, the unit of LSP
+// positions.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// path returns the chain of expressions of root containing offset, from root
+// to the innermost.
+func path(root parser.Expression, offset int) []parser.Expression {
+	var out []parser.Expression
+	parser.Inspect(root, func(e parser.Expression) bool {
+		span := e.Pos()
+		if offset < span.Start || offset >= span.End {
+			return false
+		}
+		out = append(out, e)
+		return true
+	})
+	return out
+}
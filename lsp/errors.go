@@ -0,0 +1,33 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	INVALID_CONTENT_LENGTH = "invalid Content-Length %q"
+	EXIT_WITHOUT_SHUTDOWN  = "exit without shutdown"
+	UNKNOWN_METHOD         = "unknown method %s"
+	NOT_INITIALIZED        = "server not initialized"
+)
+
+func newContentLengthError(v string) error {
+	return fmt.Errorf(INVALID_CONTENT_LENGTH, v)
+}
+
+func newExitWithoutShutdownError() error {
+	return errors.New(EXIT_WITHOUT_SHUTDOWN)
+}
+
+func newUnknownMethodError(method string) *responseError {
+	return &responseError{Code: METHOD_NOT_FOUND, Message: fmt.Sprintf(UNKNOWN_METHOD, method)}
+}
+
+func newNotInitializedError() *responseError {
+	return &responseError{Code: SERVER_NOT_INITIALIZED, Message: NOT_INITIALIZED}
+}
+
+func newInvalidParamsError(err error) *responseError {
+	return &responseError{Code: INVALID_PARAMS, Message: err.Error()}
+}
@@ -0,0 +1,76 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// JSON-RPC error codes.
+const (
+	PARSE_ERROR            = -32700
+	INVALID_REQUEST        = -32600
+	METHOD_NOT_FOUND       = -32601
+	INVALID_PARAMS         = -32602
+	INTERNAL_ERROR         = -32603
+	SERVER_NOT_INITIALIZED = -32002
+)
+
+// message is a JSON-RPC request, notification or response. Requests and
+// responses have an ID, notifications do not.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// readMessage reads a message framed by a Content-Length header.
+func readMessage(r *bufio.Reader) (*message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, newContentLengthError(header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	m := &message{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return m, &responseError{Code: PARSE_ERROR, Message: err.Error()}
+	}
+	return m, nil
+}
+
+// writeMessage writes m framed by a Content-Length header.
+func writeMessage(w io.Writer, m *message) error {
+	m.JSONRPC = "2.0"
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
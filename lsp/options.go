@@ -0,0 +1,31 @@
+package lsp
+
+import (
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/lint"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+)
+
+type Option func(s *Server)
+
+// WithSchema checks documents against the schema of their input and completes
+// its fields.
+func WithSchema(sch *schema.Schema) Option {
+	return func(s *Server) {
+		s.schema = sch
+	}
+}
+
+// WithFunctions resolves functions with r instead of functions.Default.
+func WithFunctions(r *functions.Registry) Option {
+	return func(s *Server) {
+		s.functions = r
+	}
+}
+
+// WithLintConfig configures the lint rules reported as diagnostics.
+func WithLintConfig(cfg *lint.Config) Option {
+	return func(s *Server) {
+		s.lintConfig = cfg
+	}
+}
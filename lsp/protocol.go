@@ -0,0 +1,169 @@
+package lsp
+
+// The subset of the Language Server Protocol the server speaks, see
+// https://microsoft.github.io/language-server-protocol/specification.
+
+// Position is a zero based line and UTF-16 code unit offset in the line.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// TextDocumentContentChangeEvent replaces the whole text, as the server
+// only supports full document sync.
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Version int    `json:"version"`
+	} `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DiagnosticSeverity int
+
+const (
+	SEVERITY_ERROR       DiagnosticSeverity = 1
+	SEVERITY_WARNING     DiagnosticSeverity = 2
+	SEVERITY_INFORMATION DiagnosticSeverity = 3
+)
+
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+type Diagnostic struct {
+	Range              Range                          `json:"range"`
+	Severity           DiagnosticSeverity             `json:"severity"`
+	Code               string                         `json:"code,omitempty"`
+	Source             string                         `json:"source"`
+	Message            string                         `json:"message"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    Range         `json:"range"`
+}
+
+type CompletionItemKind int
+
+const (
+	COMPLETION_FUNCTION CompletionItemKind = 3
+	COMPLETION_FIELD    CompletionItemKind = 5
+)
+
+type CompletionItem struct {
+	Label         string             `json:"label"`
+	Kind          CompletionItemKind `json:"kind"`
+	Detail        string             `json:"detail,omitempty"`
+	Documentation string             `json:"documentation,omitempty"`
+	SortText      string             `json:"sortText,omitempty"`
+	TextEdit      *TextEdit          `json:"textEdit,omitempty"`
+}
+
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokens struct {
+	Data []int `json:"data"`
+}
+
+type SemanticTokensLegend struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   ServerInfo         `json:"serverInfo"`
+}
+
+type ServerInfo struct {
+	Name string `json:"name"`
+}
+
+type ServerCapabilities struct {
+	TextDocumentSync           int                   `json:"textDocumentSync"`
+	HoverProvider              bool                  `json:"hoverProvider"`
+	DefinitionProvider         bool                  `json:"definitionProvider"`
+	DocumentFormattingProvider bool                  `json:"documentFormattingProvider"`
+	CompletionProvider         CompletionOptions     `json:"completionProvider"`
+	SemanticTokensProvider     SemanticTokensOptions `json:"semanticTokensProvider"`
+}
+
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters"`
+}
+
+type SemanticTokensOptions struct {
+	Legend SemanticTokensLegend `json:"legend"`
+	Full   bool                 `json:"full"`
+}
+
+// TEXT_DOCUMENT_SYNC_FULL makes clients send the whole text on every change.
+const TEXT_DOCUMENT_SYNC_FULL = 1
@@ -0,0 +1,471 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/format"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/lint"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+	"github.com/jorgepbrown/wildcard-tree/typecheck"
+)
+
+const NAME = "wildcard-lsp"
+
+// Server is a language server for template documents, speaking JSON-RPC over
+// a pair of streams such as stdin and stdout.
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	functions  *functions.Registry
+	schema     *schema.Schema
+	lintConfig *lint.Config
+	linter     *lint.Linter
+
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+func New(in io.Reader, out io.Writer, opts ...Option) *Server {
+	s := &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		functions: functions.Default,
+		docs:      map[string]*document{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.linter = lint.New(s.lintConfig, s.functions)
+	return s
+}
+
+// Serve handles messages until the client sends exit or closes the input. It
+// returns an error if the client exits without shutting the server down first.
+func (s *Server) Serve() error {
+	for {
+		m, err := readMessage(s.in)
+		var rerr *responseError
+		switch {
+		case errors.As(err, &rerr):
+			if err := s.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return newExitWithoutShutdownError()
+			}
+			return nil
+		}
+		if err := s.handle(m); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers requests and applies notifications. Only write errors are
+// returned.
+func (s *Server) handle(m *message) error {
+	if m.ID == nil {
+		if n, ok := notifications[m.Method]; ok && s.initialized {
+			return n(s, m.Params)
+		}
+		return nil
+	}
+	h, ok := requests[m.Method]
+	switch {
+	case !ok:
+		return s.reply(m.ID, nil, newUnknownMethodError(m.Method))
+	case !s.initialized && m.Method != "initialize":
+		return s.reply(m.ID, nil, newNotInitializedError())
+	}
+	result, err := h(s, m.Params)
+	if err != nil {
+		var rerr *responseError
+		if !errors.As(err, &rerr) {
+			rerr = &responseError{Code: INTERNAL_ERROR, Message: err.Error()}
+		}
+		return s.reply(m.ID, nil, rerr)
+	}
+	return s.reply(m.ID, result, nil)
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, rerr *responseError) error {
+	m := &message{ID: id, Error: rerr}
+	if id == nil {
+		null := json.RawMessage("null")
+		m.ID = &null
+	}
+	if rerr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		m.Result = data
+	}
+	return writeMessage(s.out, m)
+}
+
+func (s *Server) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &message{Method: method, Params: data})
+}
+
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+var requests = map[string]handler{
+	"initialize":                       request((*Server).initialize),
+	"shutdown":                         request((*Server).shutDown),
+	"textDocument/hover":               request((*Server).hover),
+	"textDocument/definition":          request((*Server).definition),
+	"textDocument/completion":          request((*Server).completion),
+	"textDocument/formatting":          request((*Server).formatting),
+	"textDocument/semanticTokens/full": request((*Server).semanticTokens),
+}
+
+var notifications = map[string]func(s *Server, params json.RawMessage) error{
+	"textDocument/didOpen":   notification((*Server).didOpen),
+	"textDocument/didChange": notification((*Server).didChange),
+	"textDocument/didClose":  notification((*Server).didClose),
+}
+
+// request decodes the params of a request into P before calling f.
+func request[P any](f func(s *Server, params P) (any, error)) handler {
+	return func(s *Server, raw json.RawMessage) (any, error) {
+		var params P
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, newInvalidParamsError(err)
+			}
+		}
+		return f(s, params)
+	}
+}
+
+// notification decodes the params of a notification into P before calling
+// f. Notifications with invalid params are dropped, as there is no one to
+// tell.
+func notification[P any](f func(s *Server, params P) error) func(*Server, json.RawMessage) error {
+	return func(s *Server, raw json.RawMessage) error {
+		var params P
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil
+		}
+		return f(s, params)
+	}
+}
+
+func (s *Server) initialize(struct{}) (any, error) {
+	s.initialized = true
+	return InitializeResult{
+		ServerInfo: ServerInfo{Name: NAME},
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           TEXT_DOCUMENT_SYNC_FULL,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentFormattingProvider: true,
+			CompletionProvider:         CompletionOptions{TriggerCharacters: []string{".", "|", "["}},
+			SemanticTokensProvider: SemanticTokensOptions{
+				Legend: SemanticTokensLegend{TokenTypes: TOKEN_TYPES, TokenModifiers: []string{}},
+				Full:   true,
+			},
+		},
+	}, nil
+}
+
+func (s *Server) shutDown(struct{}) (any, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(p DidOpenTextDocumentParams) error {
+	d := newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text)
+	s.docs[d.uri] = d
+	return s.publish(d)
+}
+
+func (s *Server) didChange(p DidChangeTextDocumentParams) error {
+	if len(p.ContentChanges) == 0 {
+		return nil
+	}
+	text := p.ContentChanges[len(p.ContentChanges)-1].Text
+	d := newDocument(p.TextDocument.URI, p.TextDocument.Version, text)
+	s.docs[d.uri] = d
+	return s.publish(d)
+}
+
+func (s *Server) didClose(p DidCloseTextDocumentParams) error {
+	delete(s.docs, p.TextDocument.URI)
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// publish sends the syntax errors, type errors, schema violations and lint
+// problems of d.
+func (s *Server) publish(d *document) error {
+	diags := append([]diag.Diagnostic{}, d.errors...)
+	for _, w := range d.wildcards {
+		diags = append(diags, typecheck.Check(w, s.schema, s.functions).Errors...)
+		if s.schema != nil {
+			diags = append(diags, schema.Check(w, s.schema)...)
+		}
+	}
+	diags = append(diags, s.linter.CheckTemplate(parser.Template{Source: d.text, Wildcards: d.wildcards})...)
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Span.Start < diags[j].Span.Start
+	})
+
+	out := []Diagnostic{}
+	seen := map[string]bool{}
+	for _, dg := range diags {
+		// the type checker and the linter both report unknown functions
+		key := fmt.Sprintf("%d:%d:%s", dg.Span.Start, dg.Span.End, dg.Code)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, s.diagnostic(d, dg))
+	}
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         d.uri,
+		Version:     d.version,
+		Diagnostics: out,
+	})
+}
+
+func (s *Server) diagnostic(d *document, dg diag.Diagnostic) Diagnostic {
+	out := Diagnostic{
+		Range:    d.rangeOf(dg.Span),
+		Severity: SEVERITY_ERROR,
+		Code:     dg.Code,
+		Source:   NAME,
+		Message:  dg.Message,
+	}
+	switch dg.Severity {
+	case diag.WARNING:
+		out.Severity = SEVERITY_WARNING
+	case diag.INFO:
+		out.Severity = SEVERITY_INFORMATION
+	}
+	for _, n := range dg.Notes {
+		out.RelatedInformation = append(out.RelatedInformation, DiagnosticRelatedInformation{
+			Location: Location{URI: d.uri, Range: d.rangeOf(n.Span)},
+			Message:  n.Message,
+		})
+	}
+	return out
+}
+
+// at returns the document and byte offset of p, or nil if the document is
+// not open.
+func (s *Server) at(p TextDocumentPositionParams) (*document, int) {
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, 0
+	}
+	return d, d.offset(p.Position)
+}
+
+// hover describes the innermost node under the cursor and its inferred type,
+// or the function it names.
+func (s *Server) hover(p TextDocumentPositionParams) (any, error) {
+	d, offset := s.at(p)
+	if d == nil {
+		return nil, nil
+	}
+	w := d.wildcard(offset)
+	if w == nil {
+		return nil, nil
+	}
+	nodes := path(w, offset)
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	node := nodes[len(nodes)-1]
+	if len(nodes) > 1 && isKey(nodes[len(nodes)-2], node) {
+		// the type of a key is its name, describe the value it reads instead
+		node = nodes[len(nodes)-2]
+	}
+
+	var value string
+	if f, ok := s.function(nodes); ok {
+		value = fmt.Sprintf("```\n%s: %s -> %s\n```\n%s", f.Name, f.Accepts, f.Returns, f.Doc)
+	} else {
+		k, _ := parser.KindOf(node.Type())
+		t := typecheck.Check(w, s.schema, s.functions).TypeOf(node)
+		text, err := format.Node(node)
+		if err != nil {
+			text = d.text[node.Pos().Start:node.Pos().End]
+		}
+		value = fmt.Sprintf("```\n%s\n```\n%s of type `%s`", text, k.Name, t)
+	}
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: value},
+		Range:    d.rangeOf(node.Pos()),
+	}, nil
+}
+
+// isKey reports whether e is the key of the access expression parent.
+func isKey(parent, e parser.Expression) bool {
+	switch p := parent.(type) {
+	case *parser.DotExpression:
+		return p.Key == e
+	case *parser.IndexExpression:
+		return p.Key == e
+	}
+	return false
+}
+
+// function returns the function named by the innermost of nodes, if it is the
+// name of a pipe.
+func (s *Server) function(nodes []parser.Expression) (*functions.Function, bool) {
+	if len(nodes) < 2 {
+		return nil, false
+	}
+	name, ok := nodes[len(nodes)-1].(*parser.Literal)
+	pipe, isPipe := nodes[len(nodes)-2].(*parser.FunctionExpression)
+	if !ok || !isPipe || pipe.Name != parser.Expression(name) {
+		return nil, false
+	}
+	return s.functions.Lookup(name.V)
+}
+
+// definition locates the Go source implementing the function under the
+// cursor.
+func (s *Server) definition(p TextDocumentPositionParams) (any, error) {
+	d, offset := s.at(p)
+	if d == nil {
+		return nil, nil
+	}
+	w := d.wildcard(offset)
+	if w == nil {
+		return nil, nil
+	}
+	f, ok := s.function(path(w, offset))
+	if !ok {
+		return nil, nil
+	}
+	file, line, ok := f.Source()
+	if !ok {
+		return nil, nil
+	}
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(file)}
+	pos := Position{Line: line - 1}
+	return Location{URI: uri.String(), Range: Range{Start: pos, End: pos}}, nil
+}
+
+// formatting rewrites every wildcard that parses in canonical form.
+func (s *Server) formatting(p DocumentFormattingParams) (any, error) {
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	edits := []TextEdit{}
+	for _, w := range d.wildcards {
+		if text, err := format.Node(w); err == nil && text != d.text[w.Start:w.End] {
+			edits = append(edits, TextEdit{Range: d.rangeOf(w.Span), NewText: text})
+		}
+	}
+	return edits, nil
+}
+
+// TOKEN_TYPES is the legend of semantic tokens, indexed by the token type
+// constants.
+var TOKEN_TYPES = []string{"variable", "property", "function", "string", "operator"}
+
+const (
+	TOKEN_VARIABLE = iota
+	TOKEN_PROPERTY
+	TOKEN_FUNCTION
+	TOKEN_STRING
+	TOKEN_OPERATOR
+)
+
+func (s *Server) semanticTokens(p SemanticTokensParams) (any, error) {
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	data := []int{}
+	var last Position
+	t := tokenizer.New("")
+	for _, w := range d.wildcards {
+		roles := tokenRoles(w)
+		t.ResetAt(d.text, w.Start)
+		for tok := t.Next(); tok.T != tokenizer.EOF && tok.Start < w.End; tok = t.Next() {
+			kind := TOKEN_OPERATOR
+			switch tok.T {
+			case tokenizer.ILLEGAL:
+				continue
+			case tokenizer.TEXT:
+				kind = roles[tok.Start]
+			}
+			pos := d.position(tok.Start)
+			char := pos.Character
+			if pos.Line == last.Line {
+				char -= last.Character
+			}
+			data = append(data, pos.Line-last.Line, char, utf16Len(d.text[tok.Start:tok.End]), kind, 0)
+			last = pos
+		}
+	}
+	return SemanticTokens{Data: data}, nil
+}
+
+// tokenRoles returns the semantic token type of every literal of w by the
+// offset it starts at: keys are properties, pipe names functions, quoted
+// values strings and bare values input variables.
+func tokenRoles(w *parser.Wildcard) map[int]int {
+	roles := map[int]int{}
+	var role func(e parser.Expression, as int)
+	role = func(e parser.Expression, as int) {
+		switch v := e.(type) {
+		case *parser.Literal:
+			if as == TOKEN_VARIABLE && v.Quoted {
+				as = TOKEN_STRING
+			}
+			roles[v.Start] = as
+		case *parser.DotExpression:
+			role(v.Target, TOKEN_VARIABLE)
+			role(v.Key, TOKEN_PROPERTY)
+		case *parser.IndexExpression:
+			role(v.Target, TOKEN_VARIABLE)
+			role(v.Key, TOKEN_PROPERTY)
+		case *parser.FunctionExpression:
+			role(v.Argument, TOKEN_VARIABLE)
+			role(v.Name, TOKEN_FUNCTION)
+		case *parser.Wildcard:
+			role(v.Expression, TOKEN_VARIABLE)
+		default:
+			for _, child := range parser.Children(e) {
+				role(child, as)
+			}
+		}
+	}
+	role(w, TOKEN_VARIABLE)
+	return roles
+}
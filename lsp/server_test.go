@@ -0,0 +1,286 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/schema"
+)
+
+const testURI = "file:///workflow.tmpl"
+
+const testSchema = `{
+	"type": "object",
+	"required": ["user"],
+	"additionalProperties": false,
+	"properties": {
+		"user": {
+			"type": "object",
+			"required": ["name"],
+			"additionalProperties": false,
+			"properties": {
+				"name": {"type": "string"},
+				"nickname": {"type": "string"},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+// run sends msgs followed by shutdown and exit to a server and returns its
+// responses by ID and its notifications in order.
+func run(t *testing.T, msgs ...*message) (map[string]*message, []*message) {
+	t.Helper()
+	s, err := schema.Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var in bytes.Buffer
+	msgs = append([]*message{newRequest(t, 0, "initialize", struct{}{})}, msgs...)
+	msgs = append(msgs, newRequest(t, 99, "shutdown", nil), newNotification(t, "exit", nil))
+	for _, m := range msgs {
+		if err := writeMessage(&in, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	if err := New(&in, &out, WithSchema(s)).Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := map[string]*message{}
+	var notifications []*message
+	r := bufio.NewReader(&out)
+	for {
+		m, err := readMessage(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ID == nil {
+			notifications = append(notifications, m)
+			continue
+		}
+		responses[string(*m.ID)] = m
+	}
+	return responses, notifications
+}
+
+func newRequest(t *testing.T, id int, method string, params any) *message {
+	raw := json.RawMessage(fmt.Sprint(id))
+	m := newNotification(t, method, params)
+	m.ID = &raw
+	return m
+}
+
+func newNotification(t *testing.T, method string, params any) *message {
+	t.Helper()
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &message{Method: method, Params: data}
+}
+
+func open(t *testing.T, text string) *message {
+	return newNotification(t, "textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, LanguageID: "wildcard", Version: 1, Text: text},
+	})
+}
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+// result decodes the result of the response with id into v.
+func result(t *testing.T, responses map[string]*message, id int, v any) {
+	t.Helper()
+	m, ok := responses[fmt.Sprint(id)]
+	if !ok {
+		t.Fatalf("no response to %d", id)
+	}
+	if m.Error != nil {
+		t.Fatalf("response %d: unexpected error %s", id, m.Error.Message)
+	}
+	if err := json.Unmarshal(m.Result, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	text := "url: {{ user.nme | toupper }}\nnext: {{ user.name ?? }} {{ (user.name) }}"
+	_, notifications := run(t, open(t, text))
+	if len(notifications) != 1 || notifications[0].Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected diagnostics, got=%v", notifications)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(notifications[0].Params, &params); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"0:8-0:16 1 unknown-property `user.nme` cannot exist, `user` has no property nme, did you mean `name`?",
+		"0:19-0:26 1 unknown-function unknown function toupper, did you mean toUpper?",
+		"1:22-1:24 1 syntax-error parser error unknown epression type WILDCARD_CLOSE",
+		"1:28-1:39 3 redundant-parens redundant parentheses",
+	}
+	var got []string
+	for _, d := range params.Diagnostics {
+		got = append(got, fmt.Sprintf("%d:%d-%d:%d %d %s %s", d.Range.Start.Line, d.Range.Start.Character,
+			d.Range.End.Line, d.Range.End.Character, d.Severity, d.Code, d.Message))
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("wrong diagnostics\nexpected=%q\ngot=%q", expected, got)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	text := "ünïcode {{ user.name | toUpper }}\n{{ user.tags[0] ?? 'x' }}\n{{user.|toUpper}} {{ user.name | t"
+	responses, _ := run(t,
+		open(t, text),
+		newRequest(t, 1, "textDocument/hover", at(0, 17)),
+		newRequest(t, 2, "textDocument/hover", at(0, 25)),
+		newRequest(t, 3, "textDocument/definition", at(0, 25)),
+		newRequest(t, 4, "textDocument/completion", at(2, 7)),
+		newRequest(t, 5, "textDocument/completion", at(2, 34)),
+		newRequest(t, 6, "textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: testURI}}),
+		newRequest(t, 7, "textDocument/semanticTokens/full", SemanticTokensParams{TextDocument: TextDocumentIdentifier{URI: testURI}}),
+		newRequest(t, 8, "textDocument/unknown", at(0, 0)),
+		newRequest(t, 9, "textDocument/hover", at(0, 3)),
+	)
+
+	var hover Hover
+	result(t, responses, 1, &hover)
+	if expected := "```\nuser.name\n```\ndot of type `string`"; hover.Contents.Value != expected {
+		t.Errorf("wrong hover, expected=%q got=%q", expected, hover.Contents.Value)
+	}
+	if expected := (Range{Start: Position{0, 11}, End: Position{0, 20}}); hover.Range != expected {
+		t.Errorf("wrong hover range, expected=%v got=%v", expected, hover.Range)
+	}
+	result(t, responses, 2, &hover)
+	if !strings.Contains(hover.Contents.Value, "toUpper: string -> string") {
+		t.Errorf("expected function hover, got=%q", hover.Contents.Value)
+	}
+
+	var loc Location
+	result(t, responses, 3, &loc)
+	if !strings.HasPrefix(loc.URI, "file:///") || !strings.HasSuffix(loc.URI, "/functions/builtin.go") {
+		t.Errorf("wrong definition, got=%v", loc)
+	}
+
+	var list CompletionList
+	result(t, responses, 4, &list)
+	if got := labels(list); !reflect.DeepEqual(got, []string{"name", "nickname", "tags"}) {
+		t.Errorf("wrong field completions, got=%v", got)
+	}
+	result(t, responses, 5, &list)
+	if got := labels(list); !reflect.DeepEqual(got, []string{"toJson", "toLower", "toNumber", "toString", "toUpper", "trim"}) {
+		t.Errorf("wrong function completions, got=%v", got)
+	}
+	if e := list.Items[0].TextEdit; e == nil || e.Range.Start.Character != 33 || e.Range.End.Character != 34 {
+		t.Errorf("wrong completion edit, got=%v", e)
+	}
+
+	var edits []TextEdit
+	result(t, responses, 6, &edits)
+	expectedEdits := []TextEdit{
+		{Range: Range{Start: Position{1, 0}, End: Position{1, 25}}, NewText: `{{ user.tags[0] ?? "x" }}`},
+	}
+	if !reflect.DeepEqual(edits, expectedEdits) {
+		t.Errorf("wrong edits, expected=%v got=%v", expectedEdits, edits)
+	}
+
+	var tokens SemanticTokens
+	result(t, responses, 7, &tokens)
+	expectedTokens := []int{
+		0, 8, 2, TOKEN_OPERATOR, 0, // {{
+		0, 3, 4, TOKEN_VARIABLE, 0, // user
+		0, 4, 1, TOKEN_OPERATOR, 0, // .
+		0, 1, 4, TOKEN_PROPERTY, 0, // name
+		0, 5, 1, TOKEN_OPERATOR, 0, // |
+		0, 2, 7, TOKEN_FUNCTION, 0, // toUpper
+		0, 8, 2, TOKEN_OPERATOR, 0, // }}
+		1, 0, 2, TOKEN_OPERATOR, 0, // {{
+		0, 3, 4, TOKEN_VARIABLE, 0, // user
+		0, 4, 1, TOKEN_OPERATOR, 0, // .
+		0, 1, 4, TOKEN_PROPERTY, 0, // tags
+		0, 4, 1, TOKEN_OPERATOR, 0, // [
+		0, 1, 1, TOKEN_PROPERTY, 0, // 0
+		0, 1, 1, TOKEN_OPERATOR, 0, // ]
+		0, 2, 2, TOKEN_OPERATOR, 0, // ??
+		0, 3, 3, TOKEN_STRING, 0, // 'x'
+		0, 4, 2, TOKEN_OPERATOR, 0, // }}
+	}
+	if !reflect.DeepEqual(tokens.Data, expectedTokens) {
+		t.Errorf("wrong semantic tokens\nexpected=%v\ngot=%v", expectedTokens, tokens.Data)
+	}
+
+	if m := responses["8"]; m == nil || m.Error == nil || m.Error.Code != METHOD_NOT_FOUND {
+		t.Errorf("expected method not found, got=%v", m)
+	}
+	if m := responses["9"]; m == nil || string(m.Result) != "null" {
+		t.Errorf("expected null hover outside wildcards, got=%v", m)
+	}
+}
+
+func labels(list CompletionList) []string {
+	out := []string{}
+	for _, item := range list.Items {
+		out = append(out, item.Label)
+	}
+	return out
+}
+
+func TestLifecycle(t *testing.T) {
+	tt := []struct {
+		name     string
+		msgs     []*message
+		expected string
+	}{
+		{"not initialized", []*message{newRequest(t, 1, "shutdown", nil)}, `{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"server not initialized"}}`},
+		{"exit without shutdown", []*message{newRequest(t, 1, "initialize", nil), newNotification(t, "exit", nil)}, ""},
+	}
+	for _, test := range tt {
+		var in, out bytes.Buffer
+		for _, m := range test.msgs {
+			writeMessage(&in, m)
+		}
+		err := New(&in, &out).Serve()
+		if test.expected == "" {
+			if err == nil || err.Error() != EXIT_WITHOUT_SHUTDOWN {
+				t.Errorf("%s: expected %s, got=%v", test.name, EXIT_WITHOUT_SHUTDOWN, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", test.name, err)
+		}
+		if _, body, _ := strings.Cut(out.String(), "\r\n\r\n"); body != test.expected {
+			t.Errorf("%s: expected=%s got=%s", test.name, test.expected, body)
+		}
+	}
+}
+
+func TestTokenRoles(t *testing.T) {
+	src := `{{ (a).("b") | (toUpper) }}`
+	d := newDocument(testURI, 1, src)
+	r := tokenRoles(d.wildcards[0])
+	expected := map[string]int{"a": TOKEN_VARIABLE, `"b"`: TOKEN_PROPERTY, "toUpper": TOKEN_FUNCTION}
+	for text, role := range expected {
+		if got := r[strings.Index(src, text)]; got != role {
+			t.Errorf("%s: wrong role, expected=%d got=%d", text, role, got)
+		}
+	}
+}
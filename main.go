@@ -1,30 +1,207 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
-	"github.com/jorgepbrown/wildcard-tree/repl"
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/highlight"
 )
 
-var step = flag.String("step", "parse", "--step=<step> to specify the step")
+const NAME = "wildcard-tree"
 
-func init() {
-	flag.Parse()
+// command is a subcommand of the CLI. run returns the exit code: 0 on
+// success, 1 if problems were found in the input and 2 on usage or I/O
+// errors.
+type command struct {
+	name string
+	args string
+	doc  string
+	run  func(args []string) int
 }
 
+// commands is filled in init, as the commands look themselves up by name.
+var commands []*command
+
 func main() {
-	r := repl.New()
-	var s repl.ParseStep
-	switch strings.ToLower(*step) {
-	case "tokenize":
-		s = repl.TOKENIZE
-	case "parse":
-		s = repl.PARSE
-	default:
-		fmt.Printf("unknown step %s", *step)
-		return
-	}
-	r.Start(s)
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return 2
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		if len(args) > 1 {
+			if c, ok := lookup(args[1]); ok {
+				return c.run([]string{"-h"})
+			}
+		}
+		usage(os.Stdout)
+		return 0
+	}
+	c, ok := lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s: unknown command %s\n", NAME, name)
+		usage(os.Stderr)
+		return 2
+	}
+	return c.run(args[1:])
+}
+
+func lookup(name string) (*command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: %s <command> [flags] [arguments]\n\ncommands:\n", NAME)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.doc)
+	}
+	fmt.Fprintf(w, "\nrun %s help <command> for the flags of a command\n", NAME)
+}
+
+// newFlagSet returns an empty flag set for the command name, printing errors
+// and usage to stderr.
+func newFlagSet(name string) *flag.FlagSet {
+	c, _ := lookup(name)
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s [flags] %s\n\n%s\n", NAME, c.name, c.args, c.doc)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nflags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseFlags parses args into fs. It returns the exit code and false if the
+// command should not run, which is after -h or invalid flags.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return 2, false
+	}
+	return 0, true
+}
+
+// multiFlag is a flag that may be given more than once.
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ", ")
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
+
+// colorFlag is the -color flag of commands printing templates or their syntax
+// errors.
+type colorFlag string
+
+func addColorFlag(fs *flag.FlagSet) *colorFlag {
+	c := colorFlag("auto")
+	fs.Var(&c, "color", "when to color templates, `mode` is auto, always or never; auto colors terminals")
+	return &c
+}
+
+func (c *colorFlag) String() string {
+	return string(*c)
+}
+
+func (c *colorFlag) Set(v string) error {
+	switch v {
+	case "auto", "always", "never":
+		*c = colorFlag(v)
+		return nil
+	}
+	return fmt.Errorf("expected auto, always or never")
+}
+
+// enabled reports whether to color the output written to f.
+func (c *colorFlag) enabled(f *os.File) bool {
+	switch *c {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return highlight.Enabled(f)
+}
+
+// outputFlag is the -output flag of commands reporting diagnostics.
+type outputFlag string
+
+const (
+	OUTPUT_PRETTY = "pretty"
+	OUTPUT_SHORT  = "short"
+	OUTPUT_JSON   = "json"
+)
+
+func (o *outputFlag) String() string {
+	return string(*o)
+}
+
+func (o *outputFlag) Set(v string) error {
+	switch v {
+	case OUTPUT_PRETTY, OUTPUT_SHORT, OUTPUT_JSON:
+		*o = outputFlag(v)
+		return nil
+	}
+	return fmt.Errorf("expected pretty, short or json")
+}
+
+// reporter prints diagnostics as selected by -output and -color.
+type reporter struct {
+	output outputFlag
+	color  *colorFlag
+}
+
+func addReporterFlags(fs *flag.FlagSet) *reporter {
+	r := &reporter{output: OUTPUT_PRETTY, color: addColorFlag(fs)}
+	fs.Var(&r.output, "output", "how diagnostics are printed, `format` is pretty, short or json")
+	return r
+}
+
+// report prints diags of in to stdout and reports whether any is an error.
+func (r *reporter) report(in input, diags []diag.Diagnostic) (bool, error) {
+	hasErrors := false
+	for _, d := range diags {
+		if d.Severity == diag.ERROR {
+			hasErrors = true
+		}
+		if r.output == OUTPUT_SHORT {
+			fmt.Printf("%s:%s\n", in.Name, d)
+		}
+	}
+	switch r.output {
+	case OUTPUT_PRETTY:
+		format := diag.TEXT
+		if r.color.enabled(os.Stdout) {
+			format = diag.ANSI
+		}
+		return hasErrors, diag.Render(os.Stdout, in.Name, in.Source, diags, format)
+	case OUTPUT_JSON:
+		return hasErrors, diag.Render(os.Stdout, in.Name, in.Source, diags, diag.JSON)
+	}
+	return hasErrors, nil
 }
@@ -0,0 +1,123 @@
+package optimize
+
+import (
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Optimize returns a simplified copy of e that evaluates to the same result.
+// It folds pure functions of fns applied to constants, drops fallbacks of ??
+// whose primary is never null and unwraps nested wildcards that only wrap a
+// literal. The root node keeps its type, so the root wildcard of an AST stays
+// a wildcard.
+func Optimize(e parser.Expression, fns *functions.Registry) parser.Expression {
+	o := &optimizer{fns: fns}
+	if w, ok := e.(*parser.Wildcard); ok {
+		return &parser.Wildcard{
+			Expression: o.value(w.Expression),
+			Span:       w.Span,
+		}
+	}
+	return o.value(e)
+}
+
+type optimizer struct {
+	fns *functions.Registry
+}
+
+// value optimizes e evaluated as a value.
+func (o *optimizer) value(e parser.Expression) parser.Expression {
+	switch v := e.(type) {
+	case *parser.Wildcard:
+		inner := o.value(v.Expression)
+		if _, ok := inner.(*parser.Literal); ok {
+			return inner
+		}
+		return &parser.Wildcard{Expression: inner, Span: v.Span}
+	case *parser.DotExpression:
+		return &parser.DotExpression{
+			Target: o.value(v.Target),
+			Key:    o.key(v.Key),
+			Span:   v.Span,
+		}
+	case *parser.IndexExpression:
+		return &parser.IndexExpression{
+			Target: o.value(v.Target),
+			Key:    o.key(v.Key),
+			Span:   v.Span,
+		}
+	case *parser.NullCoalesceExpression:
+		primary := o.value(v.Primary)
+		if isNonNull(primary) {
+			return primary
+		}
+		return &parser.NullCoalesceExpression{
+			Primary:  primary,
+			Fallback: o.value(v.Fallback),
+			Span:     v.Span,
+		}
+	case *parser.FunctionExpression:
+		arg := o.value(v.Argument)
+		if folded, ok := o.fold(v, arg); ok {
+			return folded
+		}
+		return &parser.FunctionExpression{
+			Argument: arg,
+			Name:     v.Name,
+			Span:     v.Span,
+		}
+	}
+	return e
+}
+
+// key optimizes e used as the key of a dot or index expression, where a bare
+// literal is a name and a wildcard is needed to read a value.
+func (o *optimizer) key(e parser.Expression) parser.Expression {
+	w, ok := e.(*parser.Wildcard)
+	if !ok {
+		return e
+	}
+	inner := o.value(w.Expression)
+	if isConstant(inner) {
+		return inner
+	}
+	return &parser.Wildcard{Expression: inner, Span: w.Span}
+}
+
+func (o *optimizer) fold(e *parser.FunctionExpression, arg parser.Expression) (parser.Expression, bool) {
+	if o.fns == nil || !isConstant(arg) {
+		return nil, false
+	}
+	name, ok := e.Name.(*parser.Literal)
+	if !ok {
+		return nil, false
+	}
+	f, ok := o.fns.Lookup(name.V)
+	if !ok || !f.Pure {
+		return nil, false
+	}
+	result, err := f.Apply(arg.(*parser.Literal).V)
+	if err != nil {
+		return nil, false
+	}
+	// Only strings can be written as literals.
+	s, ok := result.(string)
+	if !ok {
+		return nil, false
+	}
+	return &parser.Literal{V: s, Quoted: true, Span: e.Span}, true
+}
+
+// isConstant reports whether e is a quoted literal, which is never null.
+func isConstant(e parser.Expression) bool {
+	l, ok := e.(*parser.Literal)
+	return ok && l.Quoted
+}
+
+// isNonNull reports whether e can never evaluate to null.
+func isNonNull(e parser.Expression) bool {
+	if n, ok := e.(*parser.NullCoalesceExpression); ok {
+		return isNonNull(n.Fallback)
+	}
+	return isConstant(e)
+}
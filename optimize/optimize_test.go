@@ -0,0 +1,86 @@
+package optimize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestOptimize(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{"{{ a.b }}", "{{ a.b }}"},
+		{`{{ "a" ?? b }}`, `{{ "a" }}`},
+		{`{{ {{"x"}} }}`, `{{ "x" }}`},
+		{`{{ {{a}} }}`, `{{ a }}`},
+		{`{{ {{ {{a.b}} }} }}`, `{{ {{ {{a.b}} }} }}`},
+		{`{{ ("lit" | toUpper) }}`, `{{ "LIT" }}`},
+		{`{{ "lit" | toUpper | toLower }}`, `{{ "lit" }}`},
+		{`{{ "lit" | length }}`, `{{ "lit" | length }}`},
+		{`{{ "lit" | toupper }}`, `{{ "lit" | toupper }}`},
+		{`{{ a ?? ("b" | toUpper) ?? c }}`, `{{ a ?? "B" }}`},
+		{`{{ a.{{"b" | toUpper}} }}`, `{{ a."B" }}`},
+		{`{{ a.{{b}} }}`, `{{ a.{{b}} }}`},
+		{`{{ a[{{ {{"b"}} }}] }}`, `{{ a["b"] }}`},
+	}
+
+	for i, test := range tt {
+		t.Logf("optimize-%d %s", i, test.input)
+		actual := Optimize(mustParse(test.input, t), functions.Default)
+		expected := mustParse(test.expected, t)
+		if d := parser.Diff(expected, actual, parser.IgnoreSpans()); d != nil {
+			t.Errorf("wrong optimized tree, %s", d)
+		}
+	}
+}
+
+func TestOptimizeEvaluatesEqual(t *testing.T) {
+	inputs := []string{
+		`{{ "a" ?? b }}`,
+		`{{ a ?? ("b" | toUpper) }}`,
+		`{{ user.{{ "name" | toLower }} | toUpper }}`,
+		`{{ {{user}}.{{key}} ?? "x" | trim }}`,
+		`{{ user.tags[{{ {{"1"}} }}] }}`,
+		`{{ ("  padded  " | trim | toUpper) ?? user.name }}`,
+	}
+	datas := []string{
+		`{}`,
+		`{"a": "A", "key": "name", "user": {"name": "ada", "tags": ["x", "y"]}}`,
+		`{"a": null, "key": "missing", "user": {"name": " grace ", "tags": []}}`,
+	}
+
+	for _, input := range inputs {
+		original := mustParse(input, t)
+		optimized := Optimize(original, functions.Default)
+		for _, d := range datas {
+			var data any
+			if err := json.Unmarshal([]byte(d), &data); err != nil {
+				t.Fatal(err)
+			}
+			expected, expectedErr := eval.Evaluate(original, data, functions.Default)
+			actual, actualErr := eval.Evaluate(optimized, data, functions.Default)
+			if (expectedErr == nil) != (actualErr == nil) {
+				t.Errorf("%s on %s: wrong error, expected=%v got=%v", input, d, expectedErr, actualErr)
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("%s on %s: wrong result, expected=%v got=%v", input, d, expected, actual)
+			}
+		}
+	}
+}
+
+func mustParse(input string, t *testing.T) parser.Expression {
+	t.Helper()
+	ast, err := parser.New(tokenizer.New(input)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ast.Root
+}
@@ -0,0 +1,100 @@
+package parser
+
+import "github.com/jorgepbrown/wildcard-tree/tokenizer"
+
+// SLAB_SIZE is the number of nodes of one type allocated at once by an Arena.
+const SLAB_SIZE = 256
+
+// Arena allocates AST nodes in contiguous slabs instead of one by one. Nodes
+// stay valid as long as any node of their slab is referenced. An Arena must
+// not be used by several parsers at once.
+type Arena struct {
+	literals  slab[Literal]
+	wildcards slab[Wildcard]
+	dots      slab[DotExpression]
+	indexes   slab[IndexExpression]
+	nulls     slab[NullCoalesceExpression]
+	functions slab[FunctionExpression]
+}
+
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+type slab[T any] struct {
+	items []T
+}
+
+func (s *slab[T]) alloc() *T {
+	if len(s.items) == cap(s.items) {
+		s.items = make([]T, 0, SLAB_SIZE)
+	}
+	s.items = s.items[:len(s.items)+1]
+	return &s.items[len(s.items)-1]
+}
+
+// NewWithArena returns a Parser allocating its nodes from a.
+func NewWithArena(t *tokenizer.Tokenizer, a *Arena) *Parser {
+	p := New(t)
+	p.arena = a
+	return p
+}
+
+// ParseMany parses every template with a shared Arena. The results are in the
+// order of srcs; errs[i] is the syntax error of srcs[i], if any.
+func ParseMany(srcs []string) (asts []AST, errs []error) {
+	a := NewArena()
+	t := tokenizer.New("")
+	p := &Parser{}
+	asts = make([]AST, len(srcs))
+	errs = make([]error, len(srcs))
+	for i, src := range srcs {
+		t.Reset(src)
+		*p = Parser{t: t, arena: a}
+		p.peekToken = t.Next()
+		asts[i], errs[i] = p.Parse()
+	}
+	return asts, errs
+}
+
+func (p *Parser) newLiteral() *Literal {
+	if p.arena == nil {
+		return &Literal{}
+	}
+	return p.arena.literals.alloc()
+}
+
+func (p *Parser) newWildcard() *Wildcard {
+	if p.arena == nil {
+		return &Wildcard{}
+	}
+	return p.arena.wildcards.alloc()
+}
+
+func (p *Parser) newDotExpression() *DotExpression {
+	if p.arena == nil {
+		return &DotExpression{}
+	}
+	return p.arena.dots.alloc()
+}
+
+func (p *Parser) newIndexExpression() *IndexExpression {
+	if p.arena == nil {
+		return &IndexExpression{}
+	}
+	return p.arena.indexes.alloc()
+}
+
+func (p *Parser) newNullCoalesceExpression() *NullCoalesceExpression {
+	if p.arena == nil {
+		return &NullCoalesceExpression{}
+	}
+	return p.arena.nulls.alloc()
+}
+
+func (p *Parser) newFunctionExpression() *FunctionExpression {
+	if p.arena == nil {
+		return &FunctionExpression{}
+	}
+	return p.arena.functions.alloc()
+}
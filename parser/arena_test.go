@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestParseMany(t *testing.T) {
+	srcs := make([]string, 0, 2*SLAB_SIZE)
+	for i := 0; i < cap(srcs); i++ {
+		srcs = append(srcs, fmt.Sprintf(`{{ a.b%d[{{c}}] ?? "d" | f }}`, i))
+	}
+	srcs[7] = "{{ a"
+
+	asts, errs := ParseMany(srcs)
+	if len(asts) != len(srcs) || len(errs) != len(srcs) {
+		t.Fatalf("wrong number of results, expected=%d got=%d %d", len(srcs), len(asts), len(errs))
+	}
+	for i, src := range srcs {
+		expected, expectedErr := New(tokenizer.New(src)).Parse()
+		if (expectedErr == nil) != (errs[i] == nil) {
+			t.Fatalf("%s: wrong error, expected=%v got=%v", src, expectedErr, errs[i])
+		}
+		if expectedErr != nil {
+			continue
+		}
+		if d := Diff(expected.Root, asts[i].Root); d != nil {
+			t.Fatalf("%s: wrong tree, %s", src, d)
+		}
+	}
+}
+
+var benchmarkTemplates = func() []string {
+	srcs := make([]string, 1000)
+	for i := range srcs {
+		srcs[i] = fmt.Sprintf(`{{ node%d.output[1].items[{{ index }}] ?? "fallback" | toUpper }}`, i)
+	}
+	return srcs
+}()
+
+func BenchmarkParseEach(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, src := range benchmarkTemplates {
+			if _, err := New(tokenizer.New(src)).Parse(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParseMany(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, errs := ParseMany(benchmarkTemplates); errs[0] != nil {
+			b.Fatal(errs[0])
+		}
+	}
+}
@@ -7,17 +7,21 @@ const DOT_EXPR ExpressionType = "DOT"
 type DotExpression struct {
 	Target Expression
 	Key    Expression
+	Span
 }
 
-func (p *Parser) parseDotExpression(target Expression) (*DotExpression, error) {
+func (p *Parser) parseDotExpression(target Expression, start int) (*DotExpression, error) {
 	key, err := p.parseExpression(INDEX)
 	if err != nil {
 		return nil, err
 	}
-	return &DotExpression{
+	e := p.newDotExpression()
+	*e = DotExpression{
 		Target: target,
 		Key:    key,
-	}, nil
+		Span:   Span{Start: start, End: p.prevEnd},
+	}
+	return e, nil
 }
 
 func (e *DotExpression) Type() ExpressionType {
@@ -0,0 +1,104 @@
+package parser
+
+import "fmt"
+
+const ROOT_PATH = "Root"
+
+type compareConfig struct {
+	ignoreSpans bool
+}
+
+type CompareOption func(*compareConfig)
+
+// IgnoreSpans makes Equal and Diff disregard the source positions of nodes.
+func IgnoreSpans() CompareOption {
+	return func(c *compareConfig) {
+		c.ignoreSpans = true
+	}
+}
+
+// Difference is the first point at which two trees differ.
+type Difference struct {
+	Path     string
+	Expected Expression
+	Actual   Expression
+	Reason   string
+}
+
+func (d *Difference) String() string {
+	return fmt.Sprintf("%s: %s", d.Path, d.Reason)
+}
+
+// Equal reports whether a and b are structurally identical trees.
+func Equal(a, b Expression, opts ...CompareOption) bool {
+	return Diff(a, b, opts...) == nil
+}
+
+// Diff returns the first difference between a and b in depth-first order, or
+// nil if both trees are equal. Paths are rooted at ROOT_PATH and name the
+// struct fields leading to the node, e.g. Root.Expression.Fallback.Key.
+func Diff(a, b Expression, opts ...CompareOption) *Difference {
+	c := &compareConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c.diff(ROOT_PATH, a, b)
+}
+
+func (c *compareConfig) diff(path string, a, b Expression) *Difference {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return nil
+		}
+		return &Difference{Path: path, Expected: a, Actual: b, Reason: "expected nil and non-nil expression"}
+	}
+	if a.Type() != b.Type() {
+		return &Difference{
+			Path:     path,
+			Expected: a,
+			Actual:   b,
+			Reason:   fmt.Sprintf("type %s != %s", a.Type(), b.Type()),
+		}
+	}
+
+	if l, ok := a.(*Literal); ok {
+		r := b.(*Literal)
+		if l.V != r.V {
+			return &Difference{
+				Path:     path,
+				Expected: a,
+				Actual:   b,
+				Reason:   fmt.Sprintf("value %q != %q", l.V, r.V),
+			}
+		}
+		if l.Quoted != r.Quoted {
+			return &Difference{
+				Path:     path,
+				Expected: a,
+				Actual:   b,
+				Reason:   fmt.Sprintf("quoted %t != %t", l.Quoted, r.Quoted),
+			}
+		}
+	}
+
+	k, ok := KindOf(a.Type())
+	if !ok {
+		return &Difference{Path: path, Expected: a, Actual: b, Reason: fmt.Sprintf("unknown expression type %s", a.Type())}
+	}
+	ac, bc := k.children(a), k.children(b)
+	for i, field := range k.Fields {
+		if d := c.diff(path+"."+field, ac[i], bc[i]); d != nil {
+			return d
+		}
+	}
+
+	if !c.ignoreSpans && a.Pos() != b.Pos() {
+		return &Difference{
+			Path:     path,
+			Expected: a,
+			Actual:   b,
+			Reason:   fmt.Sprintf("span %d:%d != %d:%d", a.Pos().Start, a.Pos().End, b.Pos().Start, b.Pos().End),
+		}
+	}
+	return nil
+}
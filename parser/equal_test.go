@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestDiff(t *testing.T) {
+	tt := []struct {
+		a, b         string
+		ignoreSpans  bool
+		expectedPath string
+	}{
+		{"{{a.b}}", "{{a.b}}", false, ""},
+		{"{{a.b}}", "{{ a.b }}", true, ""},
+		{"{{a.b}}", "{{ a.b }}", false, "Root.Expression.Target"},
+		{"{{ (a | toUpper) ?? b }}", "{{ a | toUpper ?? b }}", true, "Root.Expression"},
+		{"{{ a ?? b.c }}", "{{ a ?? b.d }}", true, "Root.Expression.Fallback.Key"},
+		{"{{ a ?? b.{{c}} }}", "{{ a ?? b.c }}", true, "Root.Expression.Fallback.Key"},
+		{"{{ a[b] }}", "{{ a.b }}", true, "Root.Expression"},
+		{"{{ a[b] }}", "{{ a[\"b\"] }}", true, "Root.Expression.Key"},
+		{"{{ a['b'] }}", "{{ a[\"b\"] }}", true, ""},
+	}
+
+	for i, test := range tt {
+		t.Logf("diff-%d %s %s", i, test.a, test.b)
+		a, b := mustParse(test.a, t), mustParse(test.b, t)
+		var opts []CompareOption
+		if test.ignoreSpans {
+			opts = append(opts, IgnoreSpans())
+		}
+		d := Diff(a, b, opts...)
+		if test.expectedPath == "" {
+			if d != nil {
+				t.Errorf("expected no difference, got=%s", d)
+			}
+			if !Equal(a, b, opts...) {
+				t.Error("expected trees to be equal")
+			}
+			continue
+		}
+		if d == nil {
+			t.Errorf("expected difference at %s", test.expectedPath)
+			continue
+		}
+		if d.Path != test.expectedPath {
+			t.Errorf("wrong difference path, expected=%s got=%s", test.expectedPath, d.Path)
+		}
+		if Equal(a, b, opts...) {
+			t.Error("expected trees to differ")
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	tt := []struct {
+		input    string
+		path     string
+		expected string
+	}{
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root", `{{ a.b[c] ?? {{d}} | f }}`},
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root.Expression", `a.b[c] ?? {{d}} | f`},
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root.Expression.Argument", `a.b[c] ?? {{d}}`},
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root.Expression.Argument.Primary", `a.b[c]`},
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root.Expression.Argument.Primary.Target", `a.b`},
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root.Expression.Argument.Fallback", `{{d}}`},
+		{`{{ a.b[c] ?? {{d}} | f }}`, "Root.Expression.Name", `f`},
+		{`{{ (a | toUpper).b }}`, "Root.Expression", `(a | toUpper).b`},
+		{`{{ (a | toUpper).b }}`, "Root.Expression.Target", `a | toUpper`},
+		{`{{ (a | toUpper).b }}`, "Root.Expression.Target.Argument", `a`},
+		{`{{ (("a")) ?? b }}`, "Root.Expression", `(("a")) ?? b`},
+		{`{{ (("a")) ?? b }}`, "Root.Expression.Primary", `"a"`},
+		{`{{ a ?? (b | f) }}`, "Root.Expression", `a ?? (b | f)`},
+		{`{{ a ?? (b | f) }}`, "Root.Expression.Fallback", `b | f`},
+		{`{{ a.(b) }}`, "Root.Expression", `a.(b)`},
+		{`{{ a[(b ?? c)] }}`, "Root.Expression", `a[(b ?? c)]`},
+		{`{{ a[(b ?? c)] }}`, "Root.Expression.Key", `b ?? c`},
+	}
+
+	for _, test := range tt {
+		nodes := map[string]Expression{}
+		var walk func(path string, e Expression)
+		walk = func(path string, e Expression) {
+			nodes[path] = e
+			k, _ := KindOf(e.Type())
+			for i, child := range Children(e) {
+				walk(path+"."+k.Fields[i], child)
+			}
+		}
+		walk(ROOT_PATH, mustParse(test.input, t))
+
+		e, ok := nodes[test.path]
+		if !ok {
+			t.Fatalf("%s: no node at %s", test.input, test.path)
+		}
+		span := e.Pos()
+		if actual := test.input[span.Start:span.End]; actual != test.expected {
+			t.Errorf("%s: wrong span at %s, expected=%q got=%q", test.input, test.path, test.expected, actual)
+		}
+	}
+}
+
+func mustParse(input string, t *testing.T) Expression {
+	t.Helper()
+	ast, err := New(tokenizer.New(input)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ast.Root
+}
@@ -12,12 +12,37 @@ const (
 	MALFORMED_EXPR    = "parser error malformed expression '%s' followed by '%s'"
 )
 
-func newSyntaxError(expected, found string) error {
-	return fmt.Errorf(INVALID_SYNTAX, expected, found)
+// SyntaxError is a syntax error at Span, the token the parser stopped at.
+// Open is the opening delimiter of a missing closing one, e.g. the {{ of a
+// missing }}.
+type SyntaxError struct {
+	Span    Span
+	Message string
+	Open    *tokenizer.Token
 }
 
-func newParserUnkownExprTypeError(t tokenizer.TokenType) error {
-	return fmt.Errorf(UNKNOWN_EXPR_TYPE, t)
+func (e *SyntaxError) Error() string {
+	return e.Message
+}
+
+// newSyntaxError returns the error for found where expected was expected.
+// open is the opening delimiter expected closes, or the zero Token.
+func newSyntaxError(expected string, found, open tokenizer.Token) error {
+	e := &SyntaxError{
+		Span:    Span{Start: found.Start, End: found.End},
+		Message: fmt.Sprintf(INVALID_SYNTAX, expected, found.Literal),
+	}
+	if open.T != "" {
+		e.Open = &open
+	}
+	return e
+}
+
+func newParserUnkownExprTypeError(found tokenizer.Token) error {
+	return &SyntaxError{
+		Span:    Span{Start: found.Start, End: found.End},
+		Message: fmt.Sprintf(UNKNOWN_EXPR_TYPE, found.T),
+	}
 }
 
 func newParserMalformedExprError(l1, l2 string) error {
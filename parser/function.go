@@ -7,26 +7,30 @@ const FUNCTION ExpressionType = "FUNCTION"
 type FunctionExpression struct {
 	Argument Expression
 	Name     Expression
+	Span
 }
 
 func (w *FunctionExpression) Value() string {
 	return w.Literal()
 }
 func (w *FunctionExpression) Type() ExpressionType {
-	return NULL_COALESCE
+	return FUNCTION
 }
 
 func (w *FunctionExpression) Literal() string {
 	return fmt.Sprintf("(%s | %s)", w.Argument.Literal(), w.Name.Literal())
 }
 
-func (p *Parser) parseFunctionExpression(primary Expression) (*FunctionExpression, error) {
+func (p *Parser) parseFunctionExpression(primary Expression, start int) (*FunctionExpression, error) {
 	expr, err := p.parseExpression(PIPE)
 	if err != nil {
 		return nil, err
 	}
-	return &FunctionExpression{
+	e := p.newFunctionExpression()
+	*e = FunctionExpression{
 		Argument: primary,
 		Name:     expr,
-	}, nil
+		Span:     Span{Start: start, End: p.prevEnd},
+	}
+	return e, nil
 }
@@ -11,20 +11,25 @@ const INDEX_EXPR ExpressionType = "INDEX"
 type IndexExpression struct {
 	Target Expression
 	Key    Expression
+	Span
 }
 
-func (p *Parser) parseIndexExpression(target Expression) (*IndexExpression, error) {
+func (p *Parser) parseIndexExpression(target Expression, start int, open tokenizer.Token) (*IndexExpression, error) {
 	key, err := p.parseExpression(INDEX)
 	if err != nil {
 		return nil, err
 	}
+	end := p.currentToken.End
 	if !p.expectCurrent(tokenizer.RBRACKET) {
-		return nil, newSyntaxError("]", p.currentToken.Literal)
+		return nil, newSyntaxError("]", p.currentToken, open)
 	}
-	return &IndexExpression{
+	e := p.newIndexExpression()
+	*e = IndexExpression{
 		Target: target,
 		Key:    key,
-	}, nil
+		Span:   Span{Start: start, End: end},
+	}
+	return e, nil
 }
 
 func (e *IndexExpression) Type() ExpressionType {
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"sort"
+)
+
+// Kind describes one ExpressionType of the AST: its printable name, how many
+// child expressions it has and the struct fields holding them.
+type Kind struct {
+	Type     ExpressionType
+	Name     string
+	Arity    int
+	Fields   []string
+	children func(Expression) []Expression
+}
+
+var kinds = map[ExpressionType]*Kind{
+	LITERAL: {
+		Type:     LITERAL,
+		Name:     "literal",
+		Arity:    0,
+		Fields:   []string{},
+		children: func(Expression) []Expression { return nil },
+	},
+	WILDCARD: {
+		Type:   WILDCARD,
+		Name:   "wildcard",
+		Arity:  1,
+		Fields: []string{"Expression"},
+		children: func(e Expression) []Expression {
+			w := e.(*Wildcard)
+			return []Expression{w.Expression}
+		},
+	},
+	DOT_EXPR: {
+		Type:   DOT_EXPR,
+		Name:   "dot",
+		Arity:  2,
+		Fields: []string{"Target", "Key"},
+		children: func(e Expression) []Expression {
+			d := e.(*DotExpression)
+			return []Expression{d.Target, d.Key}
+		},
+	},
+	INDEX_EXPR: {
+		Type:   INDEX_EXPR,
+		Name:   "index",
+		Arity:  2,
+		Fields: []string{"Target", "Key"},
+		children: func(e Expression) []Expression {
+			i := e.(*IndexExpression)
+			return []Expression{i.Target, i.Key}
+		},
+	},
+	NULL_COALESCE: {
+		Type:   NULL_COALESCE,
+		Name:   "null coalesce",
+		Arity:  2,
+		Fields: []string{"Primary", "Fallback"},
+		children: func(e Expression) []Expression {
+			n := e.(*NullCoalesceExpression)
+			return []Expression{n.Primary, n.Fallback}
+		},
+	},
+	FUNCTION: {
+		Type:   FUNCTION,
+		Name:   "function",
+		Arity:  2,
+		Fields: []string{"Argument", "Name"},
+		children: func(e Expression) []Expression {
+			f := e.(*FunctionExpression)
+			return []Expression{f.Argument, f.Name}
+		},
+	},
+}
+
+// KindOf returns the registered Kind for t.
+func KindOf(t ExpressionType) (*Kind, bool) {
+	k, ok := kinds[t]
+	return k, ok
+}
+
+// Kinds returns every registered Kind ordered by type.
+func Kinds() []*Kind {
+	out := make([]*Kind, 0, len(kinds))
+	for _, k := range kinds {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+// Children returns the child expressions of e in the order of its Kind's Fields.
+func Children(e Expression) []Expression {
+	k, ok := kinds[e.Type()]
+	if !ok {
+		return nil
+	}
+	return k.children(e)
+}
+
+// Inspect traverses e in depth-first order, calling f for every node. Children
+// of a node are skipped when f returns false.
+func Inspect(e Expression, f func(Expression) bool) {
+	if e == nil || !f(e) {
+		return
+	}
+	for _, child := range Children(e) {
+		Inspect(child, f)
+	}
+}
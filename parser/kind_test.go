@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+)
+
+// TestKindsRegistered fails when an ExpressionType constant is declared in this
+// package without a matching entry in the kind registry.
+func TestKindsRegistered(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	declared := map[ExpressionType]string{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					vs := spec.(*ast.ValueSpec)
+					typ, ok := vs.Type.(*ast.Ident)
+					if !ok || typ.Name != "ExpressionType" {
+						continue
+					}
+					for i, name := range vs.Names {
+						lit, ok := vs.Values[i].(*ast.BasicLit)
+						if !ok {
+							t.Fatalf("ExpressionType %s must be a string literal", name.Name)
+						}
+						v, err := strconv.Unquote(lit.Value)
+						if err != nil {
+							t.Fatal(err)
+						}
+						declared[ExpressionType(v)] = name.Name
+					}
+				}
+			}
+		}
+	}
+
+	if len(declared) == 0 {
+		t.Fatal("no ExpressionType constants found")
+	}
+	for typ, name := range declared {
+		if _, ok := KindOf(typ); !ok {
+			t.Errorf("ExpressionType %s is not registered", name)
+		}
+	}
+	if len(declared) != len(Kinds()) {
+		t.Errorf("wrong number of registered kinds, expected=%d got=%d", len(declared), len(Kinds()))
+	}
+}
+
+func TestKinds(t *testing.T) {
+	tt := []struct {
+		expr     Expression
+		expected ExpressionType
+	}{
+		{&Literal{V: "a"}, LITERAL},
+		{&Wildcard{Expression: &Literal{V: "a"}}, WILDCARD},
+		{&DotExpression{Target: &Literal{V: "a"}, Key: &Literal{V: "b"}}, DOT_EXPR},
+		{&IndexExpression{Target: &Literal{V: "a"}, Key: &Literal{V: "b"}}, INDEX_EXPR},
+		{&NullCoalesceExpression{Primary: &Literal{V: "a"}, Fallback: &Literal{V: "b"}}, NULL_COALESCE},
+		{&FunctionExpression{Argument: &Literal{V: "a"}, Name: &Literal{V: "b"}}, FUNCTION},
+	}
+
+	for _, test := range tt {
+		if test.expr.Type() != test.expected {
+			t.Errorf("wrong expression type, expected=%s got=%s", test.expected, test.expr.Type())
+			continue
+		}
+		k, ok := KindOf(test.expr.Type())
+		if !ok {
+			t.Errorf("kind %s not registered", test.expected)
+			continue
+		}
+		if k.Type != test.expected {
+			t.Errorf("wrong kind type, expected=%s got=%s", test.expected, k.Type)
+		}
+		if k.Arity != len(k.Fields) {
+			t.Errorf("kind %s arity %d does not match fields %v", k.Type, k.Arity, k.Fields)
+		}
+		if children := Children(test.expr); len(children) != k.Arity {
+			t.Errorf("kind %s expected %d children got=%d", k.Type, k.Arity, len(children))
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	root := mustParse("{{ a.{{b}} ?? c | f }}", t)
+	var visited []ExpressionType
+	Inspect(root, func(e Expression) bool {
+		visited = append(visited, e.Type())
+		return e.Type() != DOT_EXPR
+	})
+	expected := []ExpressionType{WILDCARD, FUNCTION, NULL_COALESCE, DOT_EXPR, LITERAL, LITERAL}
+	if len(visited) != len(expected) {
+		t.Fatalf("wrong nodes visited, expected=%v got=%v", expected, visited)
+	}
+	for i := range expected {
+		if visited[i] != expected[i] {
+			t.Errorf("wrong node %d, expected=%s got=%s", i, expected[i], visited[i])
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package parser
 
+import (
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
 type Literal struct {
-	V string
+	V      string
+	Quoted bool
+	Span
 }
 
 const LITERAL ExpressionType = "LITERAL"
@@ -12,6 +20,40 @@ func (l *Literal) Value() string {
 func (l *Literal) Type() ExpressionType {
 	return LITERAL
 }
+
+// Literal returns l as written in a template. A quoted value Quote rejects
+// is printed in double quotes, which do not parse back to it.
 func (l *Literal) Literal() string {
-	return l.V
+	if !l.Quoted {
+		return l.V
+	}
+	if q, ok := Quote(l.V); ok {
+		return q
+	}
+	return `"` + l.V + `"`
+}
+
+// Quote returns s as a quoted literal, in single quotes if it contains a
+// double quote. It reports false if s cannot be written: literals have no
+// escapes, so s may not contain both quotes, and may not be empty.
+func Quote(s string) (string, bool) {
+	switch {
+	case s == "":
+		return "", false
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`, true
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'", true
+	}
+	return "", false
+}
+
+// KeyText returns name as written in a key: bare if it reads as a single word,
+// quoted otherwise. It reports false if name cannot be written, see Quote.
+func KeyText(name string) (string, bool) {
+	t := tokenizer.New(name)
+	if tok := t.Next(); tok.T == tokenizer.TEXT && !tok.Quoted && tok.Literal == name && t.Next().T == tokenizer.EOF {
+		return name, true
+	}
+	return Quote(name)
 }
@@ -9,6 +9,7 @@ const NULL_COALESCE ExpressionType = "NULL_COALESCE"
 type NullCoalesceExpression struct {
 	Primary  Expression
 	Fallback Expression
+	Span
 }
 
 func (w *NullCoalesceExpression) Value() string {
@@ -22,13 +23,16 @@ func (w *NullCoalesceExpression) Literal() string {
 	return fmt.Sprintf("(%s ?? %s)", w.Primary.Literal(), w.Fallback.Literal())
 }
 
-func (p *Parser) parseNullCoalesceExpression(primary Expression) (*NullCoalesceExpression, error) {
+func (p *Parser) parseNullCoalesceExpression(primary Expression, start int) (*NullCoalesceExpression, error) {
 	expr, err := p.parseExpression(NULL)
 	if err != nil {
 		return nil, err
 	}
-	return &NullCoalesceExpression{
+	e := p.newNullCoalesceExpression()
+	*e = NullCoalesceExpression{
 		Primary:  primary,
 		Fallback: expr,
-	}, nil
+		Span:     Span{Start: start, End: p.prevEnd},
+	}
+	return e, nil
 }
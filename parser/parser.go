@@ -8,6 +8,10 @@ type Parser struct {
 	t            *tokenizer.Tokenizer
 	peekToken    tokenizer.Token
 	currentToken tokenizer.Token
+	// prevEnd is the end of the token read before currentToken, which ends
+	// the last parsed expression including any closing parentheses
+	prevEnd int
+	arena   *Arena
 }
 
 func New(t *tokenizer.Tokenizer) *Parser {
@@ -27,6 +31,17 @@ type Expression interface {
 	Value() string
 	Literal() string // debugging
 	Type() ExpressionType
+	Pos() Span
+}
+
+// Span is the byte range [Start, End) of a node in the parsed input.
+type Span struct {
+	Start int
+	End   int
+}
+
+func (s Span) Pos() Span {
+	return s
 }
 
 type OperatorPriority uint
@@ -52,9 +67,10 @@ func (p *Parser) Parse() (AST, error) {
 
 	if p.expect(tokenizer.WILDCARD_OPEN) {
 		// c wcopen p text
+		open := p.currentToken
 		p.read()
 		// c text p wcclose
-		wc, err := p.parseWildcard()
+		wc, err := p.parseWildcard(open)
 		if err != nil {
 			return ast, err
 		}
@@ -62,31 +78,43 @@ func (p *Parser) Parse() (AST, error) {
 		return ast, nil
 	}
 
-	return ast, newSyntaxError("{{", p.currentToken.Literal)
+	return ast, newSyntaxError("{{", p.peekToken, tokenizer.Token{})
 }
 
+// parseExpression parses the expression starting at the current token. The
+// spans of operators start and end with their operands' outermost
+// parentheses, while every node keeps its own span.
 func (p *Parser) parseExpression(prio OperatorPriority) (Expression, error) {
+	start := p.currentToken.Start
 	var leftExpr Expression
 	switch p.currentToken.T {
 	case tokenizer.TEXT:
-		leftExpr = &Literal{V: p.currentToken.Literal}
+		l := p.newLiteral()
+		*l = Literal{
+			V:      p.currentToken.Literal,
+			Quoted: p.currentToken.Quoted,
+			Span:   Span{Start: p.currentToken.Start, End: p.currentToken.End},
+		}
+		leftExpr = l
 		p.read()
 	case tokenizer.WILDCARD_OPEN:
+		open := p.currentToken
 		p.read()
-		wc, err := p.parseWildcard()
+		wc, err := p.parseWildcard(open)
 		if err != nil {
 			return nil, err
 		}
 		leftExpr = wc
 	case tokenizer.LPAREN:
+		open := p.currentToken
 		p.read()
-		e, err := p.parseParenExpression()
+		e, err := p.parseParenExpression(open)
 		if err != nil {
 			return nil, err
 		}
 		leftExpr = e
 	default:
-		return nil, newParserUnkownExprTypeError(p.currentToken.T)
+		return nil, newParserUnkownExprTypeError(p.currentToken)
 	}
 
 	var err error
@@ -99,25 +127,26 @@ func (p *Parser) parseExpression(prio OperatorPriority) (Expression, error) {
 			switch p.currentToken.T {
 			case tokenizer.DOT:
 				p.read()
-				leftExpr, err = p.parseDotExpression(leftExpr)
+				leftExpr, err = p.parseDotExpression(leftExpr, start)
 				if err != nil {
 					return nil, err
 				}
 			case tokenizer.LBRACKET:
+				open := p.currentToken
 				p.read()
-				leftExpr, err = p.parseIndexExpression(leftExpr)
+				leftExpr, err = p.parseIndexExpression(leftExpr, start, open)
 				if err != nil {
 					return nil, err
 				}
 			case tokenizer.NULL_COALESCE:
 				p.read()
-				leftExpr, err = p.parseNullCoalesceExpression(leftExpr)
+				leftExpr, err = p.parseNullCoalesceExpression(leftExpr, start)
 				if err != nil {
 					return nil, err
 				}
 			case tokenizer.PIPE:
 				p.read()
-				leftExpr, err = p.parseFunctionExpression(leftExpr)
+				leftExpr, err = p.parseFunctionExpression(leftExpr, start)
 				if err != nil {
 					return nil, err
 				}
@@ -146,6 +175,7 @@ func (p *Parser) expectCurrent(t tokenizer.TokenType) bool {
 }
 
 func (p *Parser) read() bool {
+	p.prevEnd = p.currentToken.End
 	p.currentToken = p.peekToken
 	if p.currentToken.T == tokenizer.EOF {
 		return false
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/jorgepbrown/wildcard-tree/tokenizer"
@@ -52,7 +53,7 @@ func TestParser(t *testing.T) {
 			AST{
 				&Wildcard{Expression: &IndexExpression{
 					Target: &Literal{V: "a"},
-					Key:    &Literal{V: "\"a"},
+					Key:    &Literal{V: "\"a", Quoted: true},
 				}},
 			},
 		},
@@ -68,20 +69,20 @@ func TestParser(t *testing.T) {
 		{
 			`{{"{{a}}"}}`,
 			AST{
-				&Wildcard{Expression: &Literal{V: "{{a}}"}},
+				&Wildcard{Expression: &Literal{V: "{{a}}", Quoted: true}},
 			},
 		},
 		{
 			`{{ "{{a}}" }}`,
 			AST{
-				&Wildcard{Expression: &Literal{V: "{{a}}"}},
+				&Wildcard{Expression: &Literal{V: "{{a}}", Quoted: true}},
 			},
 		},
 		{
 			`{{ "{{a}}" ?? a }}`,
 			AST{
 				&Wildcard{Expression: &NullCoalesceExpression{
-					Primary:  &Literal{V: "{{a}}"},
+					Primary:  &Literal{V: "{{a}}", Quoted: true},
 					Fallback: &Literal{V: "a"},
 				}},
 			},
@@ -90,7 +91,7 @@ func TestParser(t *testing.T) {
 			`{{ "{{a}}" ?? a | toUpper }}`,
 			AST{
 				&Wildcard{Expression: &FunctionExpression{Argument: &NullCoalesceExpression{
-					Primary:  &Literal{V: "{{a}}"},
+					Primary:  &Literal{V: "{{a}}", Quoted: true},
 					Fallback: &Literal{V: "a"},
 				}, Name: &Literal{V: "toUpper"}}},
 			},
@@ -99,7 +100,7 @@ func TestParser(t *testing.T) {
 			`{{ "{{a}}" ?? (a | toUpper) }}`,
 			AST{
 				&Wildcard{Expression: &NullCoalesceExpression{
-					Primary: &Literal{V: "{{a}}"},
+					Primary: &Literal{V: "{{a}}", Quoted: true},
 					Fallback: &FunctionExpression{
 						Argument: &Literal{V: "a"},
 						Name:     &Literal{V: "toUpper"},
@@ -113,8 +114,8 @@ func TestParser(t *testing.T) {
 				&Wildcard{Expression: &FunctionExpression{
 					Argument: &Literal{V: "a"},
 					Name: &NullCoalesceExpression{
-						Primary:  &Literal{"toUpper"},
-						Fallback: &Literal{V: "{{a}}"},
+						Primary:  &Literal{V: "toUpper"},
+						Fallback: &Literal{V: "{{a}}", Quoted: true},
 					},
 				}},
 			},
@@ -125,7 +126,7 @@ func TestParser(t *testing.T) {
 				&Wildcard{Expression: &FunctionExpression{
 					Argument: &Literal{V: "a"},
 					Name: &NullCoalesceExpression{
-						Primary:  &Literal{"toUpper"},
+						Primary:  &Literal{V: "toUpper"},
 						Fallback: &Wildcard{Expression: &Literal{V: "a"}},
 					},
 				}},
@@ -182,9 +183,94 @@ func testExpr(expected, actual Expression, t *testing.T) {
 	if t.Failed() {
 		t.FailNow()
 	}
+	if d := Diff(expected, actual, IgnoreSpans()); d != nil {
+		t.Fatalf("wrong expression, %s", d)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tt := []struct {
+		input    string
+		message  string
+		span     Span
+		expected string // source of Open, if any
+	}{
+		{"a", "invalid wildcard syntax: expected '{{' found 'a'", Span{0, 1}, ""},
+		{"{{ a.b ", "invalid wildcard syntax: expected '}}' found ''", Span{7, 7}, "{{"},
+		{"{{ a ?? ", "parser error unknown epression type EOF", Span{8, 8}, ""},
+		{"{{ a[b c }}", "invalid wildcard syntax: expected ']' found 'c'", Span{7, 8}, "["},
+		{"{{ (a ?? b }}", "invalid wildcard syntax: expected ')' found '}}'", Span{11, 13}, "("},
+		{"{{ a.{{ b ] }}", "invalid wildcard syntax: expected '}}' found ']'", Span{10, 11}, "{{"},
+		{"{{ | }}", "parser error unknown epression type PIPE", Span{3, 4}, ""},
+	}
+
+	for _, tc := range tt {
+		_, err := New(tokenizer.New(tc.input)).Parse()
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Fatalf("%s: expected a SyntaxError, got %v", tc.input, err)
+		}
+		if serr.Message != tc.message || serr.Span != tc.span {
+			t.Fatalf("%s: expected %q at %v, got %q at %v", tc.input, tc.message, tc.span, serr.Message, serr.Span)
+		}
+		open := ""
+		if serr.Open != nil {
+			open = tc.input[serr.Open.Start:serr.Open.End]
+		}
+		if open != tc.expected {
+			t.Fatalf("%s: wrong opening delimiter, expected=%q got=%q", tc.input, tc.expected, open)
+		}
+	}
+}
 
-	switch v := expected.(type) {
-	case *Wildcard:
-		testExpr(v.Expression, actual.(*Wildcard).Expression, t)
+func TestQuote(t *testing.T) {
+	tt := []struct {
+		value    string
+		expected string
+	}{
+		{"a b", `"a b"`},
+		{"", ""},
+		{`say "hi"`, `'say "hi"'`},
+		{"it's", `"it's"`},
+		{`it's "hi"`, ""},
+	}
+
+	for _, tc := range tt {
+		got, ok := Quote(tc.value)
+		if ok != (tc.expected != "") || got != tc.expected {
+			t.Fatalf("%s: expected=%s got=%s", tc.value, tc.expected, got)
+		}
+		if !ok {
+			continue
+		}
+		// the quoted form parses back to the value
+		ast, err := New(tokenizer.New("{{ " + got + " }}")).Parse()
+		if err != nil {
+			t.Fatalf("%s: %s", got, err)
+		}
+		l, ok := ast.Root.Expression.(*Literal)
+		if !ok || !l.Quoted || l.V != tc.value || l.Literal() != got {
+			t.Fatalf("%s: does not round-trip, got=%#v", got, ast.Root.Expression)
+		}
+	}
+}
+
+func TestKeyText(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected string
+	}{
+		{"name", "name"},
+		{"first name", `"first name"`},
+		{"a.b", `"a.b"`},
+		{`say "hi"`, `'say "hi"'`},
+		{`it's "hi"`, ""},
+	}
+
+	for _, tc := range tt {
+		got, ok := KeyText(tc.name)
+		if ok != (tc.expected != "") || got != tc.expected {
+			t.Fatalf("%s: expected=%s got=%s", tc.name, tc.expected, got)
+		}
 	}
 }
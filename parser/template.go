@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Template is text with embedded wildcards, e.g. "https://{{host}}/{{path}}".
+// The spans of the wildcards are offsets in Source.
+type Template struct {
+	Source    string
+	Wildcards []*Wildcard
+}
+
+// ParseTemplate parses every top level wildcard in src. The text between
+// wildcards is kept as is and only available through Source.
+func ParseTemplate(src string) (Template, error) {
+	tmpl, errs := parseTemplate(src, false)
+	if len(errs) > 0 {
+		return tmpl, errs[0]
+	}
+	return tmpl, nil
+}
+
+// ParseTemplateAll is ParseTemplate continuing after syntax errors: a
+// wildcard that does not parse is skipped from its {{ past the error, and the
+// errors of all such wildcards are returned.
+func ParseTemplateAll(src string) (Template, []error) {
+	return parseTemplate(src, true)
+}
+
+func parseTemplate(src string, keepGoing bool) (Template, []error) {
+	tmpl := Template{Source: src}
+	var errs []error
+	t := tokenizer.New("")
+	p := &Parser{}
+	for pos := 0; ; {
+		i := strings.Index(src[pos:], "{{")
+		if i < 0 {
+			return tmpl, errs
+		}
+		start := pos + i
+		t.ResetAt(src, start)
+		*p = Parser{t: t}
+		p.peekToken = t.Next()
+		ast, err := p.Parse()
+		if err == nil {
+			tmpl.Wildcards = append(tmpl.Wildcards, ast.Root)
+			pos = ast.Root.End
+			continue
+		}
+		errs = append(errs, err)
+		if !keepGoing {
+			return tmpl, errs
+		}
+		pos = start + len("{{")
+		if serr, ok := err.(*SyntaxError); ok {
+			pos = max(pos, serr.Span.End)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected []string
+		err      bool
+	}{
+		{"no wildcards", nil, false},
+		{"{{a}}", []string{"{{a}}"}, false},
+		{"https://{{ host }}/users/{{ user.id }}", []string{"{{ host }}", "{{ user.id }}"}, false},
+		{"a {{ b[{{c}}] ?? 'd' }} e", []string{"{{ b[{{c}}] ?? 'd' }}"}, false},
+		{"{ not {{x}}", []string{"{{x}}"}, false},
+		{"{{a}} {{ b", nil, true},
+	}
+
+	for _, tc := range tt {
+		tmpl, err := ParseTemplate(tc.input)
+		if tc.err {
+			if err == nil {
+				t.Fatalf("%s: expected error", tc.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", tc.input, err)
+		}
+		if len(tmpl.Wildcards) != len(tc.expected) {
+			t.Fatalf("%s: wrong number of wildcards, expected=%d got=%d", tc.input, len(tc.expected), len(tmpl.Wildcards))
+		}
+		for i, w := range tmpl.Wildcards {
+			if got := tc.input[w.Start:w.End]; got != tc.expected[i] {
+				t.Fatalf("%s: wrong wildcard %d, expected=%q got=%q", tc.input, i, tc.expected[i], got)
+			}
+			if d := Diff(mustParse(tc.expected[i], t), w, IgnoreSpans()); d != nil {
+				t.Fatalf("%s: wrong tree, %s", tc.input, d)
+			}
+		}
+	}
+}
+
+func TestParseTemplateAll(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected []string
+		errs     []string
+	}{
+		{"{{a}} {{ b", []string{"{{a}}"}, []string{""}},
+		{"{{ a ?? }} {{b}} {{ c. }} d {{e}}", []string{"{{b}}", "{{e}}"}, []string{"}}", "}}"}},
+		{"{{ (a }} {{b}}", []string{"{{b}}"}, []string{"}}"}},
+	}
+
+	for _, tc := range tt {
+		tmpl, errs := ParseTemplateAll(tc.input)
+		var got []string
+		for _, w := range tmpl.Wildcards {
+			got = append(got, tc.input[w.Start:w.End])
+		}
+		if strings.Join(got, " ") != strings.Join(tc.expected, " ") {
+			t.Fatalf("%s: wrong wildcards, expected=%q got=%q", tc.input, tc.expected, got)
+		}
+		if len(errs) != len(tc.errs) {
+			t.Fatalf("%s: wrong number of errors, expected=%d got=%v", tc.input, len(tc.errs), errs)
+		}
+		for i, err := range errs {
+			var serr *SyntaxError
+			if !errors.As(err, &serr) || tc.input[serr.Span.Start:serr.Span.End] != tc.errs[i] {
+				t.Fatalf("%s: wrong error %d, expected at %q got=%v", tc.input, i, tc.errs[i], err)
+			}
+		}
+	}
+}
@@ -10,6 +10,7 @@ const WILDCARD ExpressionType = "WILDCARD"
 
 type Wildcard struct {
 	Expression Expression
+	Span
 }
 
 func (w *Wildcard) Value() string {
@@ -23,15 +24,19 @@ func (w *Wildcard) Literal() string {
 	return fmt.Sprintf("{{%s}}", w.Expression.Literal())
 }
 
-func (p *Parser) parseWildcard() (*Wildcard, error) {
+func (p *Parser) parseWildcard(open tokenizer.Token) (*Wildcard, error) {
 	expr, err := p.parseExpression(LOWEST)
 	if err != nil {
 		return nil, err
 	}
+	end := p.currentToken.End
 	if !p.expectCurrent(tokenizer.WILDCARD_CLOSE) {
-		return nil, newSyntaxError("}}", p.currentToken.Literal)
+		return nil, newSyntaxError("}}", p.currentToken, open)
 	}
-	return &Wildcard{
+	w := p.newWildcard()
+	*w = Wildcard{
 		Expression: expr,
-	}, nil
+		Span:       Span{Start: open.Start, End: end},
+	}
+	return w, nil
 }
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Format selects how AST prints a syntax tree.
+type Format int
+
+const (
+	JSON Format = iota
+	COMPACT_JSON
+	TREE
+	SEXPR
+	GRAPHVIZ
+)
+
+var formatNames = map[Format]string{
+	JSON:         "json",
+	COMPACT_JSON: "compact",
+	TREE:         "tree",
+	SEXPR:        "sexpr",
+	GRAPHVIZ:     "dot",
+}
+
+// FormatOf returns the format called name.
+func FormatOf(name string) (Format, bool) {
+	for f, n := range formatNames {
+		if n == name {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// AST writes the tree of ast to w in the format f, followed by a newline.
+func AST(w io.Writer, ast parser.AST, f Format) error {
+	var out string
+	switch f {
+	case JSON, COMPACT_JSON:
+		enc := json.NewEncoder(w)
+		if f == JSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(ast)
+	case TREE:
+		out = Tree(ast.Root)
+	case SEXPR:
+		out = SExpr(ast.Root) + "\n"
+	case GRAPHVIZ:
+		out = Graphviz(ast.Root)
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// Tree renders e as an indented ASCII tree with one node per line, e.g.
+//
+//	WILDCARD
+//	└─ DOT
+//	   ├─ LITERAL a
+//	   └─ LITERAL b
+func Tree(e parser.Expression) string {
+	var out strings.Builder
+	out.WriteString(label(e))
+	out.WriteByte('\n')
+	tree(&out, e, "")
+	return out.String()
+}
+
+func tree(out *strings.Builder, e parser.Expression, indent string) {
+	children := parser.Children(e)
+	for i, child := range children {
+		branch, next := "├─ ", "│  "
+		if i == len(children)-1 {
+			branch, next = "└─ ", "   "
+		}
+		out.WriteString(indent)
+		out.WriteString(branch)
+		out.WriteString(label(child))
+		out.WriteByte('\n')
+		tree(out, child, indent+next)
+	}
+}
+
+// label is the type of e, followed by the source of literals.
+func label(e parser.Expression) string {
+	if l, ok := e.(*parser.Literal); ok {
+		return fmt.Sprintf("%s %s", l.Type(), l.Literal())
+	}
+	return string(e.Type())
+}
+
+// SExpr renders e as an S-expression with the operator first, e.g.
+// (?? (. a b) "c") for a.b ?? "c". Index expressions are written as ([] a b),
+// functions as (| a f) and wildcards as {{x}}.
+func SExpr(e parser.Expression) string {
+	var out strings.Builder
+	sexpr(&out, e)
+	return out.String()
+}
+
+func sexpr(out *strings.Builder, e parser.Expression) {
+	var op string
+	switch v := e.(type) {
+	case *parser.Wildcard:
+		out.WriteString("{{")
+		sexpr(out, v.Expression)
+		out.WriteString("}}")
+		return
+	case *parser.DotExpression:
+		op = "."
+	case *parser.IndexExpression:
+		op = "[]"
+	case *parser.NullCoalesceExpression:
+		op = "??"
+	case *parser.FunctionExpression:
+		op = "|"
+	default:
+		out.WriteString(e.Literal())
+		return
+	}
+	out.WriteByte('(')
+	out.WriteString(op)
+	for _, child := range parser.Children(e) {
+		out.WriteByte(' ')
+		sexpr(out, child)
+	}
+	out.WriteByte(')')
+}
+
+// Graphviz renders e as a graph in the DOT language, with edges labeled by
+// the field of the parent holding the child. Render it with e.g.
+// dot -Tsvg.
+func Graphviz(e parser.Expression) string {
+	g := &graph{}
+	g.out.WriteString("digraph AST {\n\tnode [shape=box, fontname=monospace];\n")
+	g.node(e)
+	g.out.WriteString("}\n")
+	return g.out.String()
+}
+
+type graph struct {
+	out   strings.Builder
+	nodes int
+}
+
+// node writes e and the edges to its children and returns its ID.
+func (g *graph) node(e parser.Expression) string {
+	id := fmt.Sprintf("n%d", g.nodes)
+	g.nodes++
+	fmt.Fprintf(&g.out, "\t%s [label=%s];\n", id, quote(label(e)))
+
+	var fields []string
+	if k, ok := parser.KindOf(e.Type()); ok {
+		fields = k.Fields
+	}
+	for i, child := range parser.Children(e) {
+		childID := g.node(child)
+		fmt.Fprintf(&g.out, "\t%s -> %s", id, childID)
+		if i < len(fields) {
+			fmt.Fprintf(&g.out, " [label=%s]", quote(fields[i]))
+		}
+		g.out.WriteString(";\n")
+	}
+	return id
+}
+
+// quote returns s as a DOT string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
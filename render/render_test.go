@@ -0,0 +1,68 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+func TestRender(t *testing.T) {
+	tt := []struct {
+		input    string
+		render   func(parser.Expression) string
+		expected string
+	}{
+		{
+			input:  `{{ a.b[{{c}}] ?? "d" | toUpper }}`,
+			render: Tree,
+			expected: `WILDCARD
+└─ FUNCTION
+   ├─ NULL_COALESCE
+   │  ├─ INDEX
+   │  │  ├─ DOT
+   │  │  │  ├─ LITERAL a
+   │  │  │  └─ LITERAL b
+   │  │  └─ WILDCARD
+   │  │     └─ LITERAL c
+   │  └─ LITERAL "d"
+   └─ LITERAL toUpper
+`,
+		},
+		{
+			input:    `{{ a.b ?? c }}`,
+			render:   SExpr,
+			expected: `{{(?? (. a b) c)}}`,
+		},
+		{
+			input:    `{{ a[{{ b }}] ?? 'say "hi"' | f }}`,
+			render:   SExpr,
+			expected: `{{(| (?? ([] a {{b}}) 'say "hi"') f)}}`,
+		},
+		{
+			input:  `{{ a ?? 'say "hi"' }}`,
+			render: Graphviz,
+			expected: `digraph AST {
+	node [shape=box, fontname=monospace];
+	n0 [label="WILDCARD"];
+	n1 [label="NULL_COALESCE"];
+	n2 [label="LITERAL a"];
+	n1 -> n2 [label="Primary"];
+	n3 [label="LITERAL 'say \"hi\"'"];
+	n1 -> n3 [label="Fallback"];
+	n0 -> n1 [label="Expression"];
+}
+`,
+		},
+	}
+
+	for _, tc := range tt {
+		ast, err := parser.New(tokenizer.New(tc.input)).Parse()
+		if err != nil {
+			t.Fatalf("%s: %s", tc.input, err)
+		}
+		if got := tc.render(ast.Root); got != tc.expected {
+			t.Fatalf("%s: wrong rendering\nexpected:\n%s\ngot:\n%s", tc.input, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package repl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/format"
+)
+
+// command is a meta-command, entered as :name followed by its argument.
+type command struct {
+	name string
+	args string
+	doc  string
+	run  func(r *Repl, arg string) error
+}
+
+// commands is filled in init, as :help lists them.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"mode", "[tokenize|parse|eval]", "show or set what is printed for a template", (*Repl).mode},
+		{"load", "<file>", "run the template in a file", (*Repl).load},
+		{"ctx", "[file]", "show or set the JSON data templates are evaluated against", (*Repl).ctx},
+		{"fmt", "[template]", "print a template, or the last one, in canonical form", (*Repl).fmt},
+		{"history", "", "list the entered templates", (*Repl).listHistory},
+		{"help", "", "list the meta-commands", (*Repl).help},
+	}
+}
+
+func isCommand(src string) bool {
+	return strings.HasPrefix(strings.TrimSpace(src), ":")
+}
+
+// command runs the meta-command in src. Mistakes of the user are printed to
+// stderr; only write errors to stdout are returned.
+func (r *Repl) command(src string) error {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(src), ":"), " ")
+	arg = strings.TrimSpace(arg)
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(r, arg)
+		}
+	}
+	fmt.Fprintf(r.errOut, "unknown command :%s, see :help\n", name)
+	return nil
+}
+
+func (r *Repl) mode(arg string) error {
+	if arg == "" {
+		_, err := fmt.Fprintln(r.out, r.step)
+		return err
+	}
+	step, ok := ParseStepOf(arg)
+	if !ok {
+		fmt.Fprintf(r.errOut, "unknown mode %s, expected tokenize, parse or eval\n", arg)
+		return nil
+	}
+	r.step = step
+	return nil
+}
+
+func (r *Repl) load(arg string) error {
+	if arg == "" {
+		fmt.Fprintln(r.errOut, "usage: :load <file>")
+		return nil
+	}
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return nil
+	}
+	return r.run(strings.TrimSpace(string(data)))
+}
+
+func (r *Repl) ctx(arg string) error {
+	if arg == "" {
+		out, err := json.Marshal(r.data)
+		if err != nil {
+			fmt.Fprintln(r.errOut, err)
+			return nil
+		}
+		_, err = fmt.Fprintln(r.out, string(out))
+		return err
+	}
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		fmt.Fprintf(r.errOut, "%s: %s\n", arg, err)
+		return nil
+	}
+	r.data = v
+	return nil
+}
+
+func (r *Repl) fmt(arg string) error {
+	src := arg
+	if src == "" {
+		src = r.last
+	}
+	if src == "" {
+		fmt.Fprintln(r.errOut, "no template to format")
+		return nil
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		r.syntaxError(src, err)
+		return nil
+	}
+	_, err = fmt.Fprintln(r.out, r.template(out))
+	return err
+}
+
+func (r *Repl) listHistory(string) error {
+	for i, entry := range r.history.entries {
+		if _, err := fmt.Fprintf(r.out, "%4d  %s\n", i+1, r.template(entry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repl) help(string) error {
+	for _, c := range commands {
+		if _, err := fmt.Fprintf(r.out, ":%-8s %-22s %s\n", c.name, c.args, c.doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
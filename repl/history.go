@@ -0,0 +1,83 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	HISTORY_FILE = ".wildcard_tree_history"
+	MAX_HISTORY  = 1000
+)
+
+// history is the list of entered templates, persisted one per line in path.
+// It is not persisted if path is empty.
+type history struct {
+	path    string
+	entries []string
+}
+
+func newHistory(path string) *history {
+	return &history{path: path}
+}
+
+// DefaultHistoryPath returns the path of HISTORY_FILE in the home directory,
+// or "" if there is none.
+func DefaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// load reads the history file, keeping the last MAX_HISTORY entries. A missing
+// file is an empty history.
+func (h *history) load() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entries := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(entries) == 1 && entries[0] == "" {
+		entries = nil
+	}
+	if len(entries) > MAX_HISTORY {
+		entries = entries[len(entries)-MAX_HISTORY:]
+		h.entries = entries
+		return h.save()
+	}
+	h.entries = entries
+	return nil
+}
+
+// add appends entry unless it repeats the last one.
+func (h *history) add(entry string) error {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return nil
+	}
+	h.entries = append(h.entries, entry)
+	if h.path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(entry + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *history) save() error {
+	return os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+}
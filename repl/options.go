@@ -0,0 +1,54 @@
+package repl
+
+import (
+	"os"
+
+	"github.com/jorgepbrown/wildcard-tree/render"
+)
+
+type Option func(r *Repl)
+
+// WithPrompt sets the prompt and the prompt of continuation lines.
+func WithPrompt(prompt, continuation string) Option {
+	return func(r *Repl) {
+		r.prompt = prompt
+		r.continuation = continuation
+	}
+}
+
+// WithMode sets the initial mode, which :mode changes.
+func WithMode(step ParseStep) Option {
+	return func(r *Repl) {
+		r.step = step
+	}
+}
+
+// WithFormat sets how syntax trees are printed in the parse mode.
+func WithFormat(f render.Format) Option {
+	return func(r *Repl) {
+		r.format = f
+	}
+}
+
+// WithHistory keeps the history in the file at path, see DefaultHistoryPath.
+func WithHistory(path string) Option {
+	return func(r *Repl) {
+		r.history = newHistory(path)
+	}
+}
+
+// WithInterrupts discards the current input whenever c receives, e.g. from
+// signal.Notify for os.Interrupt.
+func WithInterrupts(c <-chan os.Signal) Option {
+	return func(r *Repl) {
+		r.interrupts = c
+	}
+}
+
+// WithColor highlights the templates printed by :fmt and :history with ANSI
+// colors.
+func WithColor(color bool) Option {
+	return func(r *Repl) {
+		r.color = color
+	}
+}
@@ -2,21 +2,61 @@ package repl
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/highlight"
 	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/render"
 	"github.com/jorgepbrown/wildcard-tree/tokenizer"
 )
 
-const PREFIX = "> "
+const (
+	PREFIX       = "> "
+	CONTINUATION = ".. "
+)
 
-type Repl struct{}
+type Repl struct {
+	in           io.Reader
+	out          io.Writer
+	errOut       io.Writer
+	prompt       string
+	continuation string
+	step         ParseStep
+	format       render.Format
+	interrupts   <-chan os.Signal
+	color        bool
+	history      *history
+	// data is the evaluation context set with :ctx.
+	data any
+	// last is the last template entered, used by :fmt.
+	last string
+}
 
-func New() *Repl {
-	return &Repl{}
+// New returns a Repl reading from in, printing results to out and errors to
+// errOut. By default it parses every template and prints its tree as
+// indented JSON, without history or interrupts.
+func New(in io.Reader, out, errOut io.Writer, opts ...Option) *Repl {
+	r := &Repl{
+		in:           in,
+		out:          out,
+		errOut:       errOut,
+		prompt:       PREFIX,
+		continuation: CONTINUATION,
+		step:         PARSE,
+		format:       render.JSON,
+		history:      newHistory(""),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 type ParseStep int
@@ -24,61 +64,213 @@ type ParseStep int
 const (
 	TOKENIZE ParseStep = iota
 	PARSE
+	EVAL
 )
 
-func (r *Repl) Start(step ParseStep) {
-	reader := bufio.NewReader(os.Stdin)
+var stepNames = map[ParseStep]string{
+	TOKENIZE: "tokenize",
+	PARSE:    "parse",
+	EVAL:     "eval",
+}
+
+// ParseStepOf returns the step called name.
+func ParseStepOf(name string) (ParseStep, bool) {
+	for step, n := range stepNames {
+		if n == name {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func (s ParseStep) String() string {
+	return stepNames[s]
+}
+
+// line is the result of reading one line of input.
+type line struct {
+	text string
+	err  error
+}
+
+// Start reads lines until EOF and prints their tokens, syntax tree or value
+// depending on the mode. Lines starting with : are meta-commands, see
+// :help, and lines ending inside {{ or ( are continued on the next line.
+// Errors in a line are printed to stderr and the session continues; an
+// interrupt discards the current input. Start returns nil on EOF and the
+// error otherwise if the input cannot be read or the output cannot be
+// written.
+func (r *Repl) Start() error {
+	if err := r.history.load(); err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+
+	// a line requested before returning early is never received, the buffer
+	// lets the reader send it and stop
+	lines := make(chan line, 1)
+	next := make(chan struct{})
+	defer close(next)
+	go read(bufio.NewReader(r.in), lines, next)
+
+	// input holds the lines of an incomplete template.
+	var input []string
+	// reading is set while a line is requested from the reader.
+	reading := false
 	for {
-		_, err := os.Stdout.WriteString(PREFIX)
-		if err != nil {
-			panic(err)
+		prompt := r.prompt
+		if len(input) > 0 {
+			prompt = r.continuation
 		}
-		line, isPrefix, err := reader.ReadLine()
-		if err != nil {
-			panic(err)
+		if _, err := io.WriteString(r.out, prompt); err != nil {
+			return err
+		}
+		if !reading {
+			next <- struct{}{}
+			reading = true
 		}
 
-		var out bytes.Buffer
-		out.WriteString(string(line))
-		for isPrefix {
-			line, isPrefix, err = reader.ReadLine()
-			if err != nil {
-				panic(err)
+		var l line
+		select {
+		case l = <-lines:
+			reading = false
+		case <-r.interrupts:
+			// The terminal drops the typed line, prompt for a new one.
+			input = nil
+			if _, err := io.WriteString(r.out, "\n"); err != nil {
+				return err
 			}
-			out.WriteString(string(line))
+			continue
 		}
 
-		t := tokenizer.New(out.String())
-
-		if step == TOKENIZE {
-			for {
-				token := t.Next()
-				if token.T == tokenizer.EOF {
-					break
+		if l.text != "" || len(input) > 0 {
+			input = append(input, l.text)
+			src := strings.Join(input, " ")
+			if len(input) == 1 && isCommand(src) {
+				input = nil
+				if err := r.command(src); err != nil {
+					return err
 				}
-
-				_, err := os.Stdout.WriteString(fmt.Sprintf("%s %s\n", token.T, token.Literal))
-				if err != nil {
-					panic(err)
+			} else if l.err != nil || !incomplete(src) {
+				input = nil
+				if err := r.run(src); err != nil {
+					return err
 				}
 			}
-		} else if step == PARSE {
-			p := parser.New(t)
-
-			ast, err := p.Parse()
-			if err == nil {
-				enc := json.NewEncoder(os.Stdout)
-				enc.SetIndent("  ", "  ")
-				err := enc.Encode(ast)
-				if err != nil {
-					panic(err)
-				}
-			} else {
-				_, er := os.Stderr.WriteString(err.Error())
-				if er != nil {
-					panic(er)
-				}
+		}
+		if l.err == io.EOF {
+			_, err := io.WriteString(r.out, "\n")
+			return err
+		}
+		if l.err != nil {
+			return l.err
+		}
+	}
+}
+
+// read sends a line to lines whenever next is signaled, until next is closed
+// or reading fails.
+func read(reader *bufio.Reader, lines chan<- line, next <-chan struct{}) {
+	for range next {
+		text, err := reader.ReadString('\n')
+		lines <- line{text: strings.TrimRight(text, "\r\n"), err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// incomplete reports whether src ends inside a wildcard or parentheses.
+func incomplete(src string) bool {
+	wildcards, parens := 0, 0
+	t := tokenizer.New(src)
+	for tok := t.Next(); tok.T != tokenizer.EOF; tok = t.Next() {
+		switch tok.T {
+		case tokenizer.WILDCARD_OPEN:
+			wildcards++
+		case tokenizer.WILDCARD_CLOSE:
+			wildcards--
+		case tokenizer.LPAREN:
+			parens++
+		case tokenizer.RPAREN:
+			parens--
+		}
+	}
+	return wildcards > 0 || parens > 0
+}
+
+// run records src in the history and evaluates it.
+func (r *Repl) run(src string) error {
+	if err := r.history.add(src); err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+	r.last = src
+	return r.eval(src)
+}
+
+// eval prints the tokens, syntax tree or value of input. Only write errors
+// to stdout are returned.
+func (r *Repl) eval(input string) error {
+	t := tokenizer.New(input)
+
+	if r.step == TOKENIZE {
+		for {
+			token := t.Next()
+			if token.T == tokenizer.EOF {
+				return nil
+			}
+			if _, err := fmt.Fprintf(r.out, "%s %s\n", token.T, token.Literal); err != nil {
+				return err
 			}
 		}
 	}
+
+	ast, err := parser.New(t).Parse()
+	if err != nil {
+		r.syntaxError(input, err)
+		return nil
+	}
+	if r.step == PARSE {
+		return render.AST(r.out, ast, r.format)
+	}
+
+	v, err := eval.Evaluate(ast.Root, r.data, functions.Default)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return nil
+	}
+	if s, ok := v.(string); ok {
+		_, err = fmt.Fprintln(r.out, s)
+		return err
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return nil
+	}
+	_, err = fmt.Fprintln(r.out, string(out))
+	return err
+}
+
+// template returns src highlighted if colors are enabled.
+func (r *Repl) template(src string) string {
+	if r.color {
+		return highlight.ANSI(src)
+	}
+	return src
+}
+
+// syntaxError prints err of the template src with an excerpt of src.
+func (r *Repl) syntaxError(src string, err error) {
+	d, ok := diag.FromError(err)
+	if !ok {
+		fmt.Fprintln(r.errOut, err)
+		return
+	}
+	format := diag.TEXT
+	if r.color {
+		format = diag.ANSI
+	}
+	if rerr := diag.Render(r.errOut, "", src, []diag.Diagnostic{d}, format); rerr != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
 }
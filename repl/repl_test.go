@@ -0,0 +1,201 @@
+package repl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jorgepbrown/wildcard-tree/render"
+)
+
+func TestSessions(t *testing.T) {
+	dir := t.TempDir()
+	ctx := filepath.Join(dir, "ctx.json")
+	if err := os.WriteFile(ctx, []byte(`{"user": {"name": "ada"}, "tags": ["a"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := filepath.Join(dir, "t.wc")
+	if err := os.WriteFile(tmpl, []byte("{{ user.name | toUpper }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name     string
+		input    string
+		opts     []Option
+		expected string
+		errors   string
+	}{
+		{
+			name:     "tokenize",
+			input:    "{{a}}\n",
+			opts:     []Option{WithMode(TOKENIZE)},
+			expected: "> WILDCARD_OPEN {{\nTEXT a\nWILDCARD_CLOSE }}\n> \n",
+		},
+		{
+			name:     "compact parse without trailing newline",
+			input:    "{{a}}",
+			opts:     []Option{WithFormat(render.COMPACT_JSON), WithPrompt("$ ", "| ")},
+			expected: `$ {"Root":{"Expression":{"V":"a","Quoted":false,"Start":2,"End":3},"Start":0,"End":5}}` + "\n\n",
+		},
+		{
+			name:     "syntax errors continue the session",
+			input:    "{{ a ] }}\n{{ 'b' }}\n",
+			opts:     []Option{WithMode(EVAL)},
+			expected: "> > b\n> \n",
+			errors: "error[syntax-error]: invalid wildcard syntax: expected '}}' found ']'\n" +
+				" --> 1:6\n" +
+				"  |\n" +
+				"1 | {{ a ] }}\n" +
+				"  |      ^\n" +
+				"  | -- `{{` opened here\n\n",
+		},
+		{
+			name:     "continuation",
+			input:    "{{ user\n.name ?? (\n'x') }}\n",
+			opts:     []Option{WithMode(EVAL)},
+			expected: "> .. .. x\n> \n",
+		},
+		{
+			name:     "incomplete at EOF",
+			input:    "{{ a",
+			opts:     []Option{WithMode(EVAL)},
+			expected: "> \n",
+			errors: "error[syntax-error]: invalid wildcard syntax: expected '}}' found ''\n" +
+				" --> 1:5\n" +
+				"  |\n" +
+				"1 | {{ a\n" +
+				"  |     ^\n" +
+				"  | -- `{{` opened here\n\n",
+		},
+		{
+			name:  "meta-commands",
+			input: ":mode eval\n:ctx " + ctx + "\n{{ tags[0] ?? 'none' }}\n:load " + tmpl + "\n:fmt\n:fmt {{a??(b)}}\n:mode\n:mode sing\n:bogus\n:history\n",
+			expected: "> > > a\n> ADA\n> {{ user.name | toUpper }}\n> {{ a ?? b }}\n> eval\n> > > " +
+				"   1  {{ tags[0] ?? 'none' }}\n   2  {{ user.name | toUpper }}\n> \n",
+			errors: "unknown mode sing, expected tokenize, parse or eval\nunknown command :bogus, see :help\n",
+		},
+	}
+
+	for _, tc := range tt {
+		var out, errOut bytes.Buffer
+		r := New(strings.NewReader(tc.input), &out, &errOut, tc.opts...)
+		if err := r.Start(); err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if out.String() != tc.expected {
+			t.Fatalf("%s: wrong output\nexpected=%q\ngot=     %q", tc.name, tc.expected, out.String())
+		}
+		if errOut.String() != tc.errors {
+			t.Fatalf("%s: wrong errors\nexpected=%q\ngot=     %q", tc.name, tc.errors, errOut.String())
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+	for _, input := range []string{"{{a}}\n{{a}}\n{{b}}\n", "{{c}}\n:history\n"} {
+		var out bytes.Buffer
+		r := New(strings.NewReader(input), &out, io.Discard, WithMode(TOKENIZE), WithHistory(path))
+		if err := r.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{{a}}\n{{b}}\n{{c}}\n"; string(data) != expected {
+		t.Fatalf("wrong history file, expected=%q got=%q", expected, data)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	in, w := io.Pipe()
+	interrupts := make(chan os.Signal)
+	out := &syncBuffer{}
+	r := New(in, out, io.Discard, WithMode(EVAL), WithInterrupts(interrupts))
+	done := make(chan error)
+	go func() { done <- r.Start() }()
+
+	io.WriteString(w, "{{ 'a' ??\n")
+	waitFor(t, out, "> .. ")
+	interrupts <- os.Interrupt
+	waitFor(t, out, "> .. \n> ")
+	io.WriteString(w, "{{ 'b' }}\n")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if expected := "> .. \n> b\n> \n"; out.String() != expected {
+		t.Fatalf("wrong output, expected=%q got=%q", expected, out.String())
+	}
+}
+
+func TestWriteErrorStopsReader(t *testing.T) {
+	before := runtime.NumGoroutine()
+	in, w := io.Pipe()
+	interrupts := make(chan os.Signal)
+	out := &syncBuffer{fail: "\n"}
+	r := New(in, out, io.Discard, WithInterrupts(interrupts))
+	done := make(chan error)
+	go func() { done <- r.Start() }()
+
+	waitFor(t, out, "> ")
+	interrupts <- os.Interrupt
+	if err := <-done; err != errWrite {
+		t.Fatalf("expected the write error, got=%v", err)
+	}
+	// the line requested before the error is read but never received
+	io.WriteString(w, "{{ a }}\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatal("the reader is still running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+var errWrite = errors.New("write failed")
+
+// syncBuffer is a bytes.Buffer safe to read while the REPL writes to it.
+// Writes of exactly fail, if set, return errWrite.
+type syncBuffer struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	fail string
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail != "" && string(p) == b.fail {
+		return 0, errWrite
+	}
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, out *syncBuffer, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for out.String() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q, got %q", expected, out.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/analysis"
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+)
+
+const (
+	UNKNOWN_PROPERTY    = "unknown-property"
+	INDEX_NON_ARRAY     = "index-non-array"
+	PROPERTY_NON_OBJECT = "property-non-object"
+	MISSING_FALLBACK    = "missing-fallback"
+)
+
+// Check validates every input path read by e against the schema s of the
+// input context. It reports paths that cannot exist, index access on values
+// that are not arrays and optional fields read without a ?? fallback.
+func Check(e parser.Expression, s *Schema) []diag.Diagnostic {
+	var out []diag.Diagnostic
+	for _, ref := range analysis.References(e) {
+		out = append(out, checkPath(ref, s)...)
+	}
+	return out
+}
+
+func checkPath(ref analysis.Path, s *Schema) []diag.Diagnostic {
+	var out []diag.Diagnostic
+	reportedOptional := false
+	cur := s
+	for i, seg := range ref.Segments {
+		path := analysis.Path{Segments: ref.Segments[:i+1]}
+		parent := analysis.Path{Segments: ref.Segments[:i]}
+		span := parser.Span{Start: ref.Span.Start, End: seg.Span.End}
+
+		if cur == nil {
+			return out
+		}
+		if cur.Reject {
+			return append(out, newDiagnostic(span, diag.ERROR, UNKNOWN_PROPERTY, "`%s` cannot exist", path))
+		}
+
+		// a computed key may be a name or an index, as a[x] reads like a.x
+		if seg.Dynamic {
+			return out
+		}
+		if seg.Index || (analysis.IsNumber(seg.Key) && cur.Allows(ARRAY) && !cur.Allows(OBJECT)) {
+			if !cur.Allows(ARRAY) {
+				return append(out, newDiagnostic(span, diag.ERROR, INDEX_NON_ARRAY,
+					"index access on %s of type %s", describe(parent), typeName(cur)))
+			}
+			cur = cur.Element()
+			continue
+		}
+
+		if !cur.Allows(OBJECT) {
+			return append(out, newDiagnostic(span, diag.ERROR, PROPERTY_NON_OBJECT,
+				"property access `%s` on %s of type %s", seg.Key, describe(parent), typeName(cur)))
+		}
+		prop, ok := cur.Property(seg.Key)
+		if !ok {
+			var suggestions, texts []string
+			for _, name := range cur.Suggest(seg.Key) {
+				// the root of a path is a bare literal, quoting it makes a string
+				if text, ok := parser.KeyText(name); ok && (i > 0 || text == name) {
+					suggestions = append(suggestions, name)
+					texts = append(texts, text)
+				}
+			}
+			d := newDiagnostic(span, diag.ERROR, UNKNOWN_PROPERTY,
+				"`%s` cannot exist, %s has no property %s%s", path, describe(parent), seg.Key,
+				suggest.DidYouMean(suggestions, quote))
+			d.Fixes = diag.Replacements(seg.Span, texts)
+			return append(out, d)
+		}
+		optional := prop != nil && (!cur.IsRequired(seg.Key) || prop.Nullable())
+		if optional && !ref.Guarded && !reportedOptional {
+			reportedOptional = true
+			out = append(out, newDiagnostic(span, diag.WARNING, MISSING_FALLBACK,
+				"optional field `%s` is read without a ?? fallback", path))
+		}
+		cur = prop
+	}
+	return out
+}
+
+func newDiagnostic(span parser.Span, severity diag.Severity, code, format string, args ...any) diag.Diagnostic {
+	return diag.Diagnostic{
+		Span:     span,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func describe(p analysis.Path) string {
+	if len(p.Segments) == 0 {
+		return "the input"
+	}
+	return "`" + p.String() + "`"
+}
+
+func quote(name string) string {
+	return "`" + name + "`"
+}
+
+func typeName(s *Schema) string {
+	return strings.Join(s.Type, "|")
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+)
+
+const (
+	STRING  = "string"
+	NUMBER  = "number"
+	INTEGER = "integer"
+	BOOLEAN = "boolean"
+	OBJECT  = "object"
+	ARRAY   = "array"
+	NULL    = "null"
+)
+
+// Schema is the subset of JSON Schema describing the input context of a
+// workflow step that the checker understands.
+type Schema struct {
+	Type                 Types              `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	Description          string             `json:"description"`
+	// Reject is set for the boolean schema false, which matches no value.
+	Reject bool `json:"-"`
+}
+
+// Types is the "type" keyword, which is either a single type or a list.
+type Types []string
+
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	var accept bool
+	if err := json.Unmarshal(b, &accept); err == nil {
+		*s = Schema{Reject: !accept}
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(b, (*plain)(s))
+}
+
+// Parse decodes a JSON Schema document.
+func Parse(data []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Allows reports whether values of type t may match s. A schema without a type
+// allows every type; integers are numbers.
+func (s *Schema) Allows(t string) bool {
+	if s == nil || len(s.Type) == 0 {
+		return true
+	}
+	if slices.Contains(s.Type, t) {
+		return true
+	}
+	return t == NUMBER && slices.Contains(s.Type, INTEGER)
+}
+
+// Nullable reports whether s explicitly allows null.
+func (s *Schema) Nullable() bool {
+	return s != nil && slices.Contains(s.Type, NULL)
+}
+
+func (s *Schema) IsRequired(name string) bool {
+	return s != nil && slices.Contains(s.Required, name)
+}
+
+// Property returns the schema of the property name and whether it may exist.
+// Undeclared properties are described by additionalProperties; a nil schema
+// means any value.
+func (s *Schema) Property(name string) (*Schema, bool) {
+	if s == nil {
+		return nil, true
+	}
+	if p, ok := s.Properties[name]; ok {
+		return p, true
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Reject {
+		return nil, false
+	}
+	return s.AdditionalProperties, true
+}
+
+// Element returns the schema of the elements of an array.
+func (s *Schema) Element() *Schema {
+	if s == nil {
+		return nil
+	}
+	return s.Items
+}
+
+// Names returns the declared property names of s in sorted order.
+func (s *Schema) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Suggest returns the declared property names of s that name is likely a
+// misspelling of, closest first.
+func (s *Schema) Suggest(name string) []string {
+	return suggest.Closest(name, s.Names())
+}
@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["user", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"user": {
+			"type": "object",
+			"required": ["name", "tags"],
+			"properties": {
+				"name": {"type": "string"},
+				"nickname": {"type": ["string", "null"]},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			}
+		},
+		"items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["id"],
+				"additionalProperties": false,
+				"properties": {"id": {"type": "integer"}}
+			}
+		},
+		"meta": {}
+	}
+}`
+
+func TestCheck(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		input    string
+		expected []string
+	}{
+		{"{{user.name}}", nil},
+		{"{{items[0].id}}", nil},
+		{"{{items.0.id}}", nil},
+		{"{{user.tags[1]}}", nil},
+		{`{{meta.anything.at.all ?? "x"}}`, nil},
+		{"{{user.nickname ?? user.name}}", nil},
+		{`{{user["name"]}}`, nil},
+		{"{{user.{{key}}}}", []string{
+			"9:12: error: `key` cannot exist, the input has no property key [unknown-property]",
+		}},
+		{`{{user[{{key}}] ?? user[("name")]}}`, []string{
+			"9:12: error: `key` cannot exist, the input has no property key [unknown-property]",
+		}},
+		{"{{usr.name}}", []string{
+			"2:5: error: `usr` cannot exist, the input has no property usr, did you mean `user`? [unknown-property]",
+		}},
+		{"{{items[0].name}}", []string{
+			"2:15: error: `items[*].name` cannot exist, `items[*]` has no property name [unknown-property]",
+		}},
+		{"{{user[0]}}", []string{
+			"2:8: error: index access on `user` of type object [index-non-array]",
+		}},
+		{"{{user.name.first}}", []string{
+			"2:17: error: property access `first` on `user.name` of type string [property-non-object]",
+		}},
+		{"{{user.nickname}}", []string{
+			"2:15: warning: optional field `user.nickname` is read without a ?? fallback [missing-fallback]",
+		}},
+		{"{{meta.a | toUpper}}", []string{
+			"2:6: warning: optional field `meta` is read without a ?? fallback [missing-fallback]",
+		}},
+	}
+
+	for i, test := range tt {
+		t.Logf("check-%d %s", i, test.input)
+		ast, err := parser.New(tokenizer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		diags := Check(ast.Root, s)
+		if len(diags) != len(test.expected) {
+			t.Fatalf("wrong number of diagnostics, expected=%v got=%v", test.expected, diags)
+		}
+		for j, d := range diags {
+			if d.String() != test.expected[j] {
+				t.Errorf("wrong diagnostic, expected=%s got=%s", test.expected[j], d.String())
+			}
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["user"],
+		"additionalProperties": false,
+		"properties": {
+			"user": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"name": {}, "names": {}, "first name": {}, "say \"hi\"": {}, "it's \"hi\"": {}}
+			},
+			"first name": {}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		input    string
+		expected []string
+	}{
+		{`{{ usr }}`, []string{`{{ user }}`}},
+		{`{{ user.nme }}`, []string{`{{ user.name }}`}},
+		{`{{ user.namez }}`, []string{`{{ user.name }}`, `{{ user.names }}`}},
+		{`{{ user["Name"] }}`, []string{`{{ user[name] }}`, `{{ user[names] }}`}},
+		{`{{ user.firstname }}`, []string{`{{ user."first name" }}`}},
+		{`{{ user.'say hi"' }}`, []string{`{{ user.'say "hi"' }}`}},
+		{`{{ user.'its "hi"' }}`, nil},
+		{`{{ firstname }}`, nil},
+		{`{{ other }}`, nil},
+	}
+	for _, test := range tt {
+		ast, err := parser.New(tokenizer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		diags := Check(ast.Root, s)
+		if len(diags) != 1 {
+			t.Fatalf("%s: expected one diagnostic, got=%v", test.input, diags)
+		}
+		if len(diags[0].Fixes) != len(test.expected) {
+			t.Fatalf("%s: wrong number of fixes, expected=%v got=%v", test.input, test.expected, diags[0].Fixes)
+		}
+		for i, fix := range diags[0].Fixes {
+			if !fix.Unsafe {
+				t.Errorf("%s: suggestion %q should not be applied automatically", test.input, fix.Message)
+			}
+			if got := diag.Apply(test.input, fix.Edits); got != test.expected[i] {
+				t.Errorf("%s: wrong fix, expected=%s got=%s", test.input, test.expected[i], got)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package semdiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+// Change is a single AST level difference between two template versions.
+type Change struct {
+	Path    string
+	Old     parser.Expression
+	New     parser.Expression
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Message)
+}
+
+// Compare parses both templates and returns their semantic changes. Changes
+// that only affect formatting, such as whitespace or redundant parentheses,
+// are not reported.
+func Compare(old, new string) ([]Change, error) {
+	oldAST, err := parser.New(tokenizer.New(old)).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("old: %w", err)
+	}
+	newAST, err := parser.New(tokenizer.New(new)).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("new: %w", err)
+	}
+	return CompareExpressions(oldAST.Root, newAST.Root), nil
+}
+
+// CompareExpressions returns every change between the trees old and new.
+func CompareExpressions(old, new parser.Expression) []Change {
+	var changes []Change
+	compare(parser.ROOT_PATH, nil, "", old, new, &changes)
+	return changes
+}
+
+func compare(path string, parent parser.Expression, field string, old, new parser.Expression, changes *[]Change) {
+	if parser.Equal(old, new, parser.IgnoreSpans()) {
+		return
+	}
+	change := Change{Path: path, Old: old, New: new}
+
+	if old == nil || new == nil || old.Type() != new.Type() {
+		change.Message = describeReplacement(parent, field, old, new)
+		*changes = append(*changes, change)
+		return
+	}
+
+	if o, ok := old.(*parser.Literal); ok {
+		n := new.(*parser.Literal)
+		if parent != nil && parent.Type() == parser.FUNCTION && field == "Name" {
+			change.Message = fmt.Sprintf("function `%s` replaced with `%s`", o.V, n.V)
+		} else {
+			change.Message = fmt.Sprintf("%s changed from `%s` to `%s`", role(parent, field), o.Literal(), n.Literal())
+		}
+		*changes = append(*changes, change)
+		return
+	}
+
+	k, _ := parser.KindOf(old.Type())
+	oc, nc := parser.Children(old), parser.Children(new)
+	for i, f := range k.Fields {
+		compare(path+"."+f, old, f, oc[i], nc[i], changes)
+	}
+}
+
+func describeReplacement(parent parser.Expression, field string, old, new parser.Expression) string {
+	switch {
+	case old == nil:
+		return fmt.Sprintf("%s added as `%s`", role(parent, field), new.Literal())
+	case new == nil:
+		return fmt.Sprintf("%s `%s` removed", role(parent, field), old.Literal())
+	}
+	if k, ok := parser.KindOf(new.Type()); ok {
+		for _, child := range parser.Children(new) {
+			if parser.Equal(old, child, parser.IgnoreSpans()) {
+				return fmt.Sprintf("`%s` wrapped in %s `%s`", old.Literal(), k.Name, new.Literal())
+			}
+		}
+	}
+	if k, ok := parser.KindOf(old.Type()); ok {
+		for _, child := range parser.Children(old) {
+			if parser.Equal(child, new, parser.IgnoreSpans()) {
+				return fmt.Sprintf("`%s` unwrapped from %s `%s`", new.Literal(), k.Name, old.Literal())
+			}
+		}
+	}
+	return fmt.Sprintf("%s changed from `%s` to `%s`", role(parent, field), old.Literal(), new.Literal())
+}
+
+func role(parent parser.Expression, field string) string {
+	if parent == nil {
+		return "template"
+	}
+	k, ok := parser.KindOf(parent.Type())
+	if !ok {
+		return strings.ToLower(field)
+	}
+	if k.Arity == 1 {
+		return k.Name
+	}
+	return fmt.Sprintf("%s of %s", strings.ToLower(field), k.Name)
+}
@@ -0,0 +1,52 @@
+package semdiff
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tt := []struct {
+		old, new string
+		expected []string
+	}{
+		{"{{a ?? b}}", "{{ a  ??  b }}", nil},
+		{"{{ (a ?? b) | toUpper }}", "{{a ?? b | toUpper}}", nil},
+		{"{{ a ?? (b | toUpper) }}", "{{ (a ?? b) | toUpper }}", []string{
+			"Root.Expression: wildcard changed from `(a ?? (b | toUpper))` to `((a ?? b) | toUpper)`",
+		}},
+		{"{{ a | toUpper }}", "{{ a }}", []string{
+			"Root.Expression: `a` unwrapped from function `(a | toUpper)`",
+		}},
+		{"{{(a ?? b) | toUpper}}", "{{ (a ?? b) | toUpper }}", nil},
+		{"{{a ?? b}}", "{{a ?? c}}", []string{
+			"Root.Expression.Fallback: fallback of null coalesce changed from `b` to `c`",
+		}},
+		{"{{a | toUpper}}", "{{a | toLower}}", []string{
+			"Root.Expression.Name: function `toUpper` replaced with `toLower`",
+		}},
+		{"{{a.b | toUpper}}", "{{a.c | toLower}}", []string{
+			"Root.Expression.Argument.Key: key of dot changed from `b` to `c`",
+			"Root.Expression.Name: function `toUpper` replaced with `toLower`",
+		}},
+		{"{{a}}", "{{a ?? b}}", []string{
+			"Root.Expression: `a` wrapped in null coalesce `(a ?? b)`",
+		}},
+		{"{{a.b}}", "{{a[b]}}", []string{
+			"Root.Expression: wildcard changed from `a.b` to `a[b]`",
+		}},
+	}
+
+	for i, test := range tt {
+		t.Logf("semdiff-%d %s %s", i, test.old, test.new)
+		changes, err := Compare(test.old, test.new)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != len(test.expected) {
+			t.Fatalf("wrong number of changes, expected=%d got=%v", len(test.expected), changes)
+		}
+		for j, c := range changes {
+			if c.String() != test.expected[j] {
+				t.Errorf("wrong change, expected=%s got=%s", test.expected[j], c.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+)
+
+// MAX_SUGGESTIONS is the most candidates Closest returns.
+const MAX_SUGGESTIONS = 3
+
+// Distance returns the edit distance between a and b in runes: the number
+// of insertions, deletions, substitutions and transpositions of adjacent
+// runes turning a into b.
+func Distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// rows i-2, i-1 and i of the distance matrix
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
+
+// Closest returns up to MAX_SUGGESTIONS candidates that name is likely a
+// misspelling of, closest first. Case is ignored when measuring, so
+// candidates only differing in case always match; others must be within a
+// third of the length of name, at least 1.
+func Closest(name string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+		exact    int
+	}
+	lower := strings.ToLower(name)
+	limit := max(1, len([]rune(name))/3)
+	var matches []match
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		d := Distance(lower, strings.ToLower(c))
+		if d <= limit {
+			matches = append(matches, match{name: c, distance: d, exact: Distance(name, c)})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.exact != b.exact {
+			return a.exact < b.exact
+		}
+		return a.name < b.name
+	})
+	out := make([]string, 0, MAX_SUGGESTIONS)
+	for i := 0; i < len(matches) && i < MAX_SUGGESTIONS; i++ {
+		out = append(out, matches[i].name)
+	}
+	return out
+}
+
+// Join lists names for a message, e.g. "a, b or c", each formatted by quote
+// unless it is nil.
+func Join(names []string, quote func(string) string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = n
+		if quote != nil {
+			quoted[i] = quote(n)
+		}
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// DidYouMean returns the suffix ", did you mean x?" listing names, or "" if
+// there are none.
+func DidYouMean(names []string, quote func(string) string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return ", did you mean " + Join(names, quote) + "?"
+}
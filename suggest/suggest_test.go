@@ -0,0 +1,79 @@
+package suggest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tt := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"toupper", "toUpper", 1},
+		{"usr", "user", 1},
+		{"lenght", "length", 1},
+		{"kitten", "sitting", 3},
+		{"naïve", "naive", 1},
+	}
+	for _, tc := range tt {
+		if got := Distance(tc.a, tc.b); got != tc.expected {
+			t.Fatalf("%q %q: expected=%d got=%d", tc.a, tc.b, tc.expected, got)
+		}
+		if got := Distance(tc.b, tc.a); got != tc.expected {
+			t.Fatalf("%q %q: not symmetric, expected=%d got=%d", tc.b, tc.a, tc.expected, got)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	names := []string{"keys", "length", "toJson", "toLower", "toNumber", "toString", "toUpper", "trim"}
+	tt := []struct {
+		name     string
+		expected []string
+	}{
+		{"toupper", []string{"toUpper"}},
+		{"TOUPPER", []string{"toUpper"}},
+		{"lenght", []string{"length"}},
+		{"toLowr", []string{"toLower"}},
+		{"key", []string{"keys"}},
+		{"tim", []string{"trim"}},
+		{"toUpper", []string{}},
+		{"fromYaml", []string{}},
+		{"toStrin", []string{"toString"}},
+	}
+	for _, tc := range tt {
+		if got := Closest(tc.name, names); !slices.Equal(got, tc.expected) {
+			t.Fatalf("%s: expected=%v got=%v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	quote := func(s string) string { return fmt.Sprintf("`%s`", s) }
+	for n, expected := range []string{"", "`a`", "`a` or `b`", "`a`, `b` or `c`"} {
+		if got := Join([]string{"a", "b", "c"}[:n], quote); got != expected {
+			t.Fatalf("expected=%s got=%s", expected, got)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	tt := []struct {
+		names    []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"toUpper"}, ", did you mean toUpper?"},
+		{[]string{"keys", "key"}, ", did you mean keys or key?"},
+	}
+	for _, tc := range tt {
+		if got := DidYouMean(tc.names, nil); got != tc.expected {
+			t.Fatalf("%v: expected=%q got=%q", tc.names, tc.expected, got)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package tokenizer
 type Token struct {
 	T       TokenType
 	Literal string
+	Start   int // byte offset of the first character
+	End     int // byte offset after the last character
+	Quoted  bool
 }
 
 type TokenType string
@@ -31,3 +34,11 @@ func newToken(t TokenType, literal string) Token {
 		Literal: literal,
 	}
 }
+
+func newQuotedToken(literal string) Token {
+	return Token{
+		T:       TEXT,
+		Literal: literal,
+		Quoted:  true,
+	}
+}
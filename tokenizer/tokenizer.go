@@ -1,7 +1,6 @@
 package tokenizer
 
 import (
-	"bytes"
 	"unicode"
 )
 
@@ -18,32 +17,59 @@ func New(input string) *Tokenizer {
 	}
 }
 
+// Reset makes t tokenize input from the start.
+func (t *Tokenizer) Reset(input string) {
+	*t = Tokenizer{
+		input: input,
+	}
+}
+
+// ResetAt makes t tokenize input from the byte offset. Token positions stay
+// relative to the start of input.
+func (t *Tokenizer) ResetAt(input string, offset int) {
+	*t = Tokenizer{
+		input:        input,
+		peekPosition: offset,
+	}
+}
+
 func (t *Tokenizer) Next() Token {
 	ch := t.read()
+	for ch == ' ' {
+		ch = t.read()
+	}
 
+	start := min(t.position, len(t.input))
+	tok := t.next(ch)
+	tok.Start = start
+	tok.End = min(t.position+1, len(t.input))
+	return tok
+}
+
+func (t *Tokenizer) next(ch byte) Token {
 	switch ch {
 	case '{':
 		if t.expect('{') {
 			return newToken(WILDCARD_OPEN, "{{")
 		} else {
-			return newToken(LBRACE, string(ch))
+			return newToken(LBRACE, "{")
 		}
 	case '}':
 		if t.expect('}') {
 			return newToken(WILDCARD_CLOSE, "}}")
 		} else {
-			return newToken(RBRACE, string(ch))
+			return newToken(RBRACE, "}")
 		}
 	case '\'':
 		if c := t.read(); c == 0 {
 			return newToken(EOF, "")
 		}
-		return newToken(TEXT, t.readWord('\''))
+		return newQuotedToken(t.readWord('\''))
 	case '"':
 		if c := t.read(); c == 0 {
 			return newToken(EOF, "")
 		}
-		return newToken(TEXT, t.readWord('"'))
+		return newQuotedToken(t.readWord('"'))
 	case '?':
 		if t.expect('?') {
 			return newToken(NULL_COALESCE, "??")
@@ -51,19 +77,17 @@ func (t *Tokenizer) Next() Token {
 			return newToken(QUESTION_MARK, "?")
 		}
 	case '|':
-		return newToken(PIPE, string(ch))
+		return newToken(PIPE, "|")
 	case '[':
-		return newToken(LBRACKET, string(ch))
+		return newToken(LBRACKET, "[")
 	case ']':
-		return newToken(RBRACKET, string(ch))
+		return newToken(RBRACKET, "]")
 	case '(':
-		return newToken(LPAREN, string(ch))
+		return newToken(LPAREN, "(")
 	case ')':
-		return newToken(RPAREN, string(ch))
+		return newToken(RPAREN, ")")
 	case '.':
-		return newToken(DOT, string(ch))
-	case ' ':
-		return t.Next()
+		return newToken(DOT, ".")
 	case 0:
 		return newToken(EOF, "")
 	default:
@@ -71,7 +95,7 @@ func (t *Tokenizer) Next() Token {
 			word := t.readWord(0)
 			return newToken(TEXT, word)
 		}
-		return newToken(ILLEGAL, string(ch))
+		return newToken(ILLEGAL, t.input[t.position:t.position+1])
 	}
 }
 
@@ -99,27 +123,29 @@ func (t *Tokenizer) isNumber(b byte) bool {
 	return b >= '0' && b <= '9'
 }
 
+// readWord returns the word starting at the current character as a slice of
+// the input. Quoted words end before terminator, which is consumed.
 func (t *Tokenizer) readWord(terminator byte) string {
-	var out bytes.Buffer
-	out.WriteByte(t.current)
+	start := t.position
 
 	ch := t.peek()
 	if terminator != 0 {
 		for ch != 0 && ch != terminator {
-			out.WriteByte(t.read())
+			t.read()
 			ch = t.peek()
 		}
+		end := t.position + 1
 		if ch != 0 {
 			t.read()
 		}
-	} else {
-		for t.isLetter(ch) || t.isNumber(ch) {
-			out.WriteByte(t.read())
-			ch = t.peek()
-		}
+		return t.input[start:end]
 	}
 
-	return out.String()
+	for t.isLetter(ch) || t.isNumber(ch) {
+		t.read()
+		ch = t.peek()
+	}
+	return t.input[start : t.position+1]
 }
 
 func (t *Tokenizer) read() byte {
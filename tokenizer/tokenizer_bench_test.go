@@ -0,0 +1,82 @@
+package tokenizer
+
+import (
+	"bytes"
+	"testing"
+)
+
+const benchmarkInput = `{{ node.output[1].items[{{ index }}] ?? "fallback value" | toUpper ?? ('other' | toLower) }}`
+
+func BenchmarkTokenizer(b *testing.B) {
+	benchmarkTokenizer(b, func(tok Token) string { return tok.Literal })
+}
+
+// BenchmarkTokenizerCopying compares BenchmarkTokenizer with the copying path
+// it replaced: it builds every word in a bytes.Buffer as readWord did before
+// literals were sliced from the input. The old tokenizer also copied while
+// scanning, so this is a lower bound of its allocations.
+func BenchmarkTokenizerCopying(b *testing.B) {
+	benchmarkTokenizer(b, func(tok Token) string {
+		if tok.T != TEXT {
+			return tok.Literal
+		}
+		var out bytes.Buffer
+		for i := 0; i < len(tok.Literal); i++ {
+			out.WriteByte(tok.Literal[i])
+		}
+		return out.String()
+	})
+}
+
+// benchmarkTokenizer tokenizes benchmarkInput, passing every token through
+// literal, and reports the allocations per token.
+func benchmarkTokenizer(b *testing.B, literal func(Token) string) {
+	t := New(benchmarkInput)
+	tokens := 0
+	for ; t.Next().T != EOF; tokens++ {
+	}
+	run := func() {
+		t := New(benchmarkInput)
+		for tok := t.Next(); tok.T != EOF; tok = t.Next() {
+			sink = literal(tok)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		run()
+	}
+	b.StopTimer()
+	allocs := testing.AllocsPerRun(100, run)
+	b.ReportMetric(float64(tokens), "tokens/op")
+	b.ReportMetric(allocs/float64(tokens), "allocs/token")
+}
+
+// sink keeps the literals of the benchmarks from being optimized away.
+var sink string
+
+func TestTokenizerZeroAlloc(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		t := New(benchmarkInput)
+		for t.Next().T != EOF {
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got=%v", allocs)
+	}
+
+	tokenizer := New(benchmarkInput)
+	for tok := tokenizer.Next(); tok.T != EOF; tok = tokenizer.Next() {
+		if tok.T != TEXT {
+			continue
+		}
+		start := tok.Start
+		if tok.Quoted {
+			start++
+		}
+		if benchmarkInput[start:start+len(tok.Literal)] != tok.Literal {
+			t.Errorf("literal %q does not match the input at %d", tok.Literal, start)
+		}
+	}
+}
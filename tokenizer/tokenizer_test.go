@@ -206,3 +206,29 @@ func TestTokenizer(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenizerPositions(t *testing.T) {
+	input := `{{ a.b["c d"] ?? 'e' }}`
+	expected := []Token{
+		{T: WILDCARD_OPEN, Literal: "{{", Start: 0, End: 2},
+		{T: TEXT, Literal: "a", Start: 3, End: 4},
+		{T: DOT, Literal: ".", Start: 4, End: 5},
+		{T: TEXT, Literal: "b", Start: 5, End: 6},
+		{T: LBRACKET, Literal: "[", Start: 6, End: 7},
+		{T: TEXT, Literal: "c d", Start: 7, End: 12, Quoted: true},
+		{T: RBRACKET, Literal: "]", Start: 12, End: 13},
+		{T: NULL_COALESCE, Literal: "??", Start: 14, End: 16},
+		{T: TEXT, Literal: "e", Start: 17, End: 20, Quoted: true},
+		{T: WILDCARD_CLOSE, Literal: "}}", Start: 21, End: 23},
+		{T: EOF, Literal: "", Start: 23, End: 23},
+		{T: EOF, Literal: "", Start: 23, End: 23},
+	}
+
+	tokenizer := New(input)
+	for _, tok := range expected {
+		actual := tokenizer.Next()
+		if actual != tok {
+			t.Fatalf("wrong token, expected=%+v got=%+v", tok, actual)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package typecheck
+
+import (
+	"fmt"
+
+	"github.com/jorgepbrown/wildcard-tree/analysis"
+	"github.com/jorgepbrown/wildcard-tree/diag"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+	"github.com/jorgepbrown/wildcard-tree/suggest"
+	"github.com/jorgepbrown/wildcard-tree/types"
+)
+
+const (
+	TYPE_MISMATCH    = "type-mismatch"
+	UNKNOWN_FUNCTION = "unknown-function"
+	INVALID_ACCESS   = "invalid-access"
+	INVALID_FUNCTION = "invalid-function"
+)
+
+// Result is a tree annotated with the inferred type of every node.
+type Result struct {
+	Types  map[parser.Expression]types.Type
+	Errors []diag.Diagnostic
+}
+
+// TypeOf returns the inferred type of e, or ANY for nodes that were not
+// checked.
+func (r *Result) TypeOf(e parser.Expression) types.Type {
+	if t, ok := r.Types[e]; ok {
+		return t
+	}
+	return types.ANY
+}
+
+// Check infers the type of every node of e. Input paths are typed by the
+// schema s of the input context and functions by the registry fns; either may
+// be nil, in which case paths are of type any and every function is unknown.
+func Check(e parser.Expression, s *schema.Schema, fns *functions.Registry) *Result {
+	c := &checker{
+		fns: fns,
+		result: &Result{
+			Types: map[parser.Expression]types.Type{},
+		},
+	}
+	c.check(e, s)
+	return c.result
+}
+
+type checker struct {
+	fns    *functions.Registry
+	result *Result
+}
+
+// info is the type of a node and, for values read from the input, the schema
+// describing it.
+type info struct {
+	t      types.Type
+	schema *schema.Schema
+}
+
+func (c *checker) check(e parser.Expression, root *schema.Schema) info {
+	var i info
+	switch v := e.(type) {
+	case *parser.Literal:
+		if v.Quoted {
+			i = info{t: types.STRING}
+		} else {
+			i = c.property(info{t: types.OBJECT, schema: root}, v.V)
+		}
+	case *parser.Wildcard:
+		i = c.check(v.Expression, root)
+	case *parser.DotExpression:
+		target := c.check(v.Target, root)
+		i = c.access(v, target, v.Key, false, root)
+	case *parser.IndexExpression:
+		target := c.check(v.Target, root)
+		i = c.access(v, target, v.Key, true, root)
+	case *parser.NullCoalesceExpression:
+		primary := c.check(v.Primary, root)
+		fallback := c.check(v.Fallback, root)
+		i = info{t: primary.t.Without(types.NULL) | fallback.t}
+	case *parser.FunctionExpression:
+		arg := c.check(v.Argument, root)
+		i = c.call(v, arg)
+	default:
+		i = info{t: types.ANY}
+	}
+	c.result.Types[e] = i.t
+	return i
+}
+
+func (c *checker) access(e parser.Expression, target info, key parser.Expression, index bool, root *schema.Schema) info {
+	if target.t == types.NULL {
+		c.key(key, root)
+		return info{t: types.NULL}
+	}
+
+	l, ok := key.(*parser.Literal)
+	if !ok {
+		c.check(key, root)
+		if !target.t.Overlaps(types.OBJECT | types.ARRAY) {
+			c.errorf(e.Pos(), INVALID_ACCESS, "cannot access %s of %s", key.Literal(), target.t)
+			return info{t: types.ANY}
+		}
+		if index && target.t.Without(types.NULL) == types.ARRAY {
+			return c.element(target)
+		}
+		return info{t: types.ANY}
+	}
+
+	c.result.Types[key] = types.STRING
+	if analysis.IsNumber(l.V) && target.t.Overlaps(types.ARRAY) && (index || !target.t.Overlaps(types.OBJECT)) {
+		return c.element(target)
+	}
+	if !target.t.Overlaps(types.OBJECT) {
+		c.errorf(e.Pos(), INVALID_ACCESS, "cannot access %s of %s", l.V, target.t)
+		return info{t: types.ANY}
+	}
+	return c.property(target, l.V)
+}
+
+func (c *checker) key(key parser.Expression, root *schema.Schema) {
+	if l, ok := key.(*parser.Literal); ok {
+		c.result.Types[l] = types.STRING
+		return
+	}
+	c.check(key, root)
+}
+
+func (c *checker) property(target info, name string) info {
+	if target.schema == nil {
+		return info{t: types.ANY}
+	}
+	prop, ok := target.schema.Property(name)
+	if !ok {
+		return info{t: types.NULL}
+	}
+	t := types.FromSchema(prop)
+	if !target.schema.IsRequired(name) || target.t.Overlaps(types.NULL) {
+		t |= types.NULL
+	}
+	return info{t: t, schema: prop}
+}
+
+func (c *checker) element(target info) info {
+	if target.schema == nil {
+		return info{t: types.ANY}
+	}
+	elem := target.schema.Element()
+	return info{t: types.FromSchema(elem) | types.NULL, schema: elem}
+}
+
+func (c *checker) call(e *parser.FunctionExpression, arg info) info {
+	name, ok := e.Name.(*parser.Literal)
+	if !ok {
+		c.errorf(e.Name.Pos(), INVALID_FUNCTION, "function name must be a literal, got %s", e.Name.Literal())
+		return info{t: types.ANY}
+	}
+	c.result.Types[name] = types.STRING
+
+	var f *functions.Function
+	if c.fns != nil {
+		f, ok = c.fns.Lookup(name.V)
+	}
+	if f == nil || !ok {
+		var suggestions []string
+		if c.fns != nil {
+			suggestions = c.fns.Suggest(name.V)
+		}
+		c.errorf(name.Span, UNKNOWN_FUNCTION, "unknown function %s%s", name.V, suggest.DidYouMean(suggestions, nil))
+		c.last().Fixes = diag.Replacements(name.Span, suggestions)
+		return info{t: types.ANY}
+	}
+
+	got := arg.t.Without(types.NULL)
+	if arg.t == types.NULL {
+		got = types.NULL
+	}
+	if arg.t != types.ANY && !f.Accepts.Has(got) {
+		c.errorf(e.Argument.Pos(), TYPE_MISMATCH, "%s expects %s, got %s", f.Name, f.Accepts, got)
+	}
+	return info{t: f.ResultType(arg.t)}
+}
+
+func (c *checker) errorf(span parser.Span, code, format string, args ...any) {
+	c.result.Errors = append(c.result.Errors, diag.Diagnostic{
+		Span:     span,
+		Severity: diag.ERROR,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// last returns the error reported last.
+func (c *checker) last() *diag.Diagnostic {
+	return &c.result.Errors[len(c.result.Errors)-1]
+}
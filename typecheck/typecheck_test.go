@@ -0,0 +1,96 @@
+package typecheck
+
+import (
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/schema"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+	"github.com/jorgepbrown/wildcard-tree/types"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["user", "items"],
+	"properties": {
+		"user": {
+			"type": "object",
+			"required": ["name", "tags"],
+			"properties": {
+				"name": {"type": "string"},
+				"nickname": {"type": ["string", "null"]},
+				"age": {"type": "integer"},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			}
+		},
+		"items": {
+			"type": "array",
+			"items": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}
+		}
+	}
+}`
+
+func TestCheck(t *testing.T) {
+	s, err := schema.Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		input    string
+		expected types.Type
+		errors   []string
+	}{
+		{`{{"a"}}`, types.STRING, nil},
+		{"{{user}}", types.OBJECT, nil},
+		{"{{user.name}}", types.STRING, nil},
+		{"{{user.nickname}}", types.STRING | types.NULL, nil},
+		{`{{user.nickname ?? "anonymous"}}`, types.STRING, nil},
+		{"{{user.age ?? user.name}}", types.STRING | types.NUMBER, nil},
+		{"{{user.tags[0]}}", types.STRING | types.NULL, nil},
+		{"{{items[0].id}}", types.NUMBER | types.NULL, nil},
+		{"{{user.name | toUpper}}", types.STRING, nil},
+		{"{{user.nickname | toUpper}}", types.STRING | types.NULL, nil},
+		{"{{user.tags | length}}", types.NUMBER, nil},
+		{"{{unknown.path}}", types.ANY, nil},
+		{"{{user.tags | toUpper}}", types.STRING, []string{
+			"2:11: error: toUpper expects string, got array [type-mismatch]",
+		}},
+		{"{{user.name | toupper}}", types.ANY, []string{
+			"14:21: error: unknown function toupper, did you mean toUpper? [unknown-function]",
+		}},
+		{"{{user.name.first}}", types.ANY, []string{
+			"2:17: error: cannot access first of string [invalid-access]",
+		}},
+		{"{{user.age[0]}}", types.ANY, []string{
+			"2:13: error: cannot access 0 of number|null [invalid-access]",
+		}},
+	}
+
+	for i, test := range tt {
+		t.Logf("typecheck-%d %s", i, test.input)
+		ast, err := parser.New(tokenizer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := Check(ast.Root, s, functions.Default)
+		if actual := r.TypeOf(ast.Root); actual != test.expected {
+			t.Errorf("wrong type, expected=%s got=%s", test.expected, actual)
+		}
+		if len(r.Errors) != len(test.errors) {
+			t.Fatalf("wrong number of errors, expected=%v got=%v", test.errors, r.Errors)
+		}
+		for j, e := range r.Errors {
+			if e.String() != test.errors[j] {
+				t.Errorf("wrong error, expected=%s got=%s", test.errors[j], e.String())
+			}
+		}
+		parser.Inspect(ast.Root, func(e parser.Expression) bool {
+			if _, ok := r.Types[e]; !ok {
+				t.Errorf("node %s has no type", e.Literal())
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/jorgepbrown/wildcard-tree/schema"
+)
+
+// Type is a union of the basic types a wildcard expression can evaluate to.
+type Type uint8
+
+const (
+	STRING Type = 1 << iota
+	NUMBER
+	BOOL
+	OBJECT
+	ARRAY
+	NULL
+
+	NEVER Type = 0
+	ANY   Type = STRING | NUMBER | BOOL | OBJECT | ARRAY | NULL
+)
+
+var names = []struct {
+	t    Type
+	name string
+}{
+	{STRING, "string"},
+	{NUMBER, "number"},
+	{BOOL, "bool"},
+	{OBJECT, "object"},
+	{ARRAY, "array"},
+	{NULL, "null"},
+}
+
+func (t Type) String() string {
+	switch t {
+	case NEVER:
+		return "never"
+	case ANY:
+		return "any"
+	}
+	var out []string
+	for _, n := range names {
+		if t&n.t != 0 {
+			out = append(out, n.name)
+		}
+	}
+	return strings.Join(out, "|")
+}
+
+// Has reports whether every type in u is part of t.
+func (t Type) Has(u Type) bool {
+	return t&u == u
+}
+
+// Overlaps reports whether t and u share a type.
+func (t Type) Overlaps(u Type) bool {
+	return t&u != 0
+}
+
+func (t Type) Without(u Type) Type {
+	return t &^ u
+}
+
+// FromSchema returns the types allowed by s.
+func FromSchema(s *schema.Schema) Type {
+	if s == nil || len(s.Type) == 0 {
+		if s != nil && s.Reject {
+			return NEVER
+		}
+		return ANY
+	}
+	var t Type
+	for _, name := range s.Type {
+		switch name {
+		case schema.STRING:
+			t |= STRING
+		case schema.NUMBER, schema.INTEGER:
+			t |= NUMBER
+		case schema.BOOLEAN:
+			t |= BOOL
+		case schema.OBJECT:
+			t |= OBJECT
+		case schema.ARRAY:
+			t |= ARRAY
+		case schema.NULL:
+			t |= NULL
+		}
+	}
+	return t
+}
+
+// Of returns the type of a JSON decoded value.
+func Of(v any) Type {
+	switch v.(type) {
+	case nil:
+		return NULL
+	case string:
+		return STRING
+	case float64, int, int64:
+		return NUMBER
+	case bool:
+		return BOOL
+	case map[string]any:
+		return OBJECT
+	case []any:
+		return ARRAY
+	}
+	return ANY
+}
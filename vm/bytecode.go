@@ -0,0 +1,266 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+// MAGIC starts every serialized Bytecode, followed by VERSION.
+const (
+	MAGIC   = "WCBC"
+	VERSION = 1
+)
+
+// Bytecode is a compiled template. Instructions are an opcode followed by its
+// operand in big endian order.
+type Bytecode struct {
+	Instructions []byte
+	Constants    []string
+}
+
+// Compile turns e into Bytecode. Functions are looked up by name when the
+// bytecode is run, so it can be shipped to workers with their own registry.
+func Compile(e parser.Expression) (*Bytecode, error) {
+	c := &compiler{
+		bc:        &Bytecode{},
+		constants: map[string]int{},
+	}
+	if err := c.compile(e); err != nil {
+		return nil, err
+	}
+	return c.bc, nil
+}
+
+type compiler struct {
+	bc        *Bytecode
+	constants map[string]int
+}
+
+func (c *compiler) compile(e parser.Expression) error {
+	switch v := e.(type) {
+	case *parser.Literal:
+		if v.Quoted {
+			return c.emitConstant(OP_CONST, v.V)
+		}
+		return c.emitConstant(OP_LOAD, v.V)
+	case *parser.Wildcard:
+		return c.compile(v.Expression)
+	case *parser.DotExpression:
+		return c.access(v.Target, v.Key)
+	case *parser.IndexExpression:
+		return c.access(v.Target, v.Key)
+	case *parser.NullCoalesceExpression:
+		if err := c.compile(v.Primary); err != nil {
+			return err
+		}
+		jump := c.emit(OP_JUMP_NOT_NULL, 0)
+		if err := c.compile(v.Fallback); err != nil {
+			return err
+		}
+		end := len(c.bc.Instructions)
+		if end > math.MaxUint16 {
+			return newInvalidOperandError(end, OP_JUMP_NOT_NULL, jump)
+		}
+		binary.BigEndian.PutUint16(c.bc.Instructions[jump+1:], uint16(end))
+		return nil
+	case *parser.FunctionExpression:
+		if err := c.compile(v.Argument); err != nil {
+			return err
+		}
+		name, ok := v.Name.(*parser.Literal)
+		if !ok {
+			return newInvalidFunctionNameError(v.Name)
+		}
+		return c.emitConstant(OP_CALL, name.V)
+	}
+	return newUnknownExprTypeError(e.Type())
+}
+
+func (c *compiler) access(target, key parser.Expression) error {
+	if err := c.compile(target); err != nil {
+		return err
+	}
+	if l, ok := key.(*parser.Literal); ok {
+		return c.emitConstant(OP_GET, l.V)
+	}
+	if err := c.compile(key); err != nil {
+		return err
+	}
+	c.emit(OP_GET_DYNAMIC, 0)
+	return nil
+}
+
+func (c *compiler) emitConstant(op Opcode, s string) error {
+	i, ok := c.constants[s]
+	if !ok {
+		i = len(c.bc.Constants)
+		if i > math.MaxUint16 {
+			return newTooManyConstantsError()
+		}
+		c.bc.Constants = append(c.bc.Constants, s)
+		c.constants[s] = i
+	}
+	c.emit(op, i)
+	return nil
+}
+
+// emit appends an instruction and returns its position.
+func (c *compiler) emit(op Opcode, operand int) int {
+	pos := len(c.bc.Instructions)
+	c.bc.Instructions = append(c.bc.Instructions, byte(op))
+	if definitions[op].operands == 2 {
+		c.bc.Instructions = binary.BigEndian.AppendUint16(c.bc.Instructions, uint16(operand))
+	}
+	return pos
+}
+
+// decode reads the instruction at pos and returns it with the position of the
+// next instruction.
+func (b *Bytecode) decode(pos int) (Opcode, int, int, error) {
+	op := Opcode(b.Instructions[pos])
+	def, ok := definitions[op]
+	if !ok {
+		return 0, 0, 0, newUnknownOpcodeError(op, pos)
+	}
+	next := pos + 1 + def.operands
+	if next > len(b.Instructions) {
+		return 0, 0, 0, newTruncatedBytecodeError(pos)
+	}
+	operand := 0
+	if def.operands == 2 {
+		operand = int(binary.BigEndian.Uint16(b.Instructions[pos+1:]))
+	}
+	return op, operand, next, nil
+}
+
+// Validate checks that every instruction is well formed and every operand is
+// in range.
+func (b *Bytecode) Validate() error {
+	for pos := 0; pos < len(b.Instructions); {
+		op, operand, next, err := b.decode(pos)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case OP_CONST, OP_LOAD, OP_GET, OP_CALL:
+			if operand >= len(b.Constants) {
+				return newInvalidOperandError(operand, op, pos)
+			}
+		case OP_JUMP_NOT_NULL:
+			if operand <= pos || operand > len(b.Instructions) {
+				return newInvalidOperandError(operand, op, pos)
+			}
+		}
+		pos = next
+	}
+	return nil
+}
+
+// Disassemble writes one line per instruction with its position, opcode and
+// operand.
+func (b *Bytecode) Disassemble(w io.Writer) error {
+	for pos := 0; pos < len(b.Instructions); {
+		op, operand, next, err := b.decode(pos)
+		if err != nil {
+			return err
+		}
+		var line string
+		switch op {
+		case OP_CONST, OP_LOAD, OP_GET, OP_CALL:
+			line = fmt.Sprintf("%04d %s %d %s", pos, op, operand, b.constant(operand))
+		case OP_JUMP_NOT_NULL:
+			line = fmt.Sprintf("%04d %s %04d", pos, op, operand)
+		default:
+			line = fmt.Sprintf("%04d %s", pos, op)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		pos = next
+	}
+	return nil
+}
+
+func (b *Bytecode) String() string {
+	var out bytes.Buffer
+	if err := b.Disassemble(&out); err != nil {
+		out.WriteString(err.Error())
+	}
+	return out.String()
+}
+
+func (b *Bytecode) constant(i int) string {
+	if i < len(b.Constants) {
+		return strconv.Quote(b.Constants[i])
+	}
+	return "?"
+}
+
+// MarshalBinary serializes b as MAGIC, VERSION, the constants and the
+// instructions, each prefixed with its length.
+func (b *Bytecode) MarshalBinary() ([]byte, error) {
+	if len(b.Constants) > math.MaxUint16 {
+		return nil, newTooManyConstantsError()
+	}
+	out := []byte(MAGIC)
+	out = append(out, VERSION)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(b.Constants)))
+	for _, c := range b.Constants {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(c)))
+		out = append(out, c...)
+	}
+	out = binary.BigEndian.AppendUint32(out, uint32(len(b.Instructions)))
+	out = append(out, b.Instructions...)
+	return out, nil
+}
+
+// UnmarshalBinary decodes and validates bytecode serialized by MarshalBinary.
+func (b *Bytecode) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	header := make([]byte, len(MAGIC)+1)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(MAGIC)]) != MAGIC || header[len(MAGIC)] != VERSION {
+		return newInvalidHeaderError()
+	}
+
+	var count uint16
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return newTruncatedBytecodeError(len(data) - r.Len())
+	}
+	constants := make([]string, count)
+	for i := range constants {
+		s, err := readBytes(r, len(data))
+		if err != nil {
+			return err
+		}
+		constants[i] = string(s)
+	}
+	instructions, err := readBytes(r, len(data))
+	if err != nil {
+		return err
+	}
+
+	decoded := &Bytecode{Instructions: instructions, Constants: constants}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*b = *decoded
+	return nil
+}
+
+func readBytes(r *bytes.Reader, size int) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil || int64(n) > int64(r.Len()) {
+		return nil, newTruncatedBytecodeError(size - r.Len())
+	}
+	out := make([]byte, n)
+	if _, err := io.ReadFull(r, out); err != nil {
+		return nil, newTruncatedBytecodeError(size - r.Len())
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jorgepbrown/wildcard-tree/parser"
+)
+
+const (
+	INVALID_FUNCTION_NAME = "function name must be a literal, got %s"
+	UNKNOWN_EXPR_TYPE     = "cannot compile expression type %s"
+	UNKNOWN_OPCODE        = "unknown opcode %d at %d"
+	INVALID_OPERAND       = "invalid operand %d of %s at %d"
+	TRUNCATED_BYTECODE    = "truncated bytecode at %d"
+	INVALID_HEADER        = "invalid bytecode header"
+	STACK_UNDERFLOW       = "stack underflow at %d"
+	INVALID_STACK         = "program ended with %d values on the stack"
+	TOO_MANY_CONSTANTS    = "too many constants"
+	UNKNOWN_FUNCTION      = "unknown function %s"
+)
+
+func newInvalidFunctionNameError(e parser.Expression) error {
+	return fmt.Errorf(INVALID_FUNCTION_NAME, e.Literal())
+}
+
+func newUnknownExprTypeError(t parser.ExpressionType) error {
+	return fmt.Errorf(UNKNOWN_EXPR_TYPE, t)
+}
+
+func newUnknownOpcodeError(op Opcode, pos int) error {
+	return fmt.Errorf(UNKNOWN_OPCODE, byte(op), pos)
+}
+
+func newInvalidOperandError(operand int, op Opcode, pos int) error {
+	return fmt.Errorf(INVALID_OPERAND, operand, op, pos)
+}
+
+func newTruncatedBytecodeError(pos int) error {
+	return fmt.Errorf(TRUNCATED_BYTECODE, pos)
+}
+
+func newInvalidHeaderError() error {
+	return errors.New(INVALID_HEADER)
+}
+
+func newStackUnderflowError(pos int) error {
+	return fmt.Errorf(STACK_UNDERFLOW, pos)
+}
+
+func newInvalidStackError(size int) error {
+	return fmt.Errorf(INVALID_STACK, size)
+}
+
+func newTooManyConstantsError() error {
+	return errors.New(TOO_MANY_CONSTANTS)
+}
+
+func newUnknownFunctionError(name string) error {
+	return fmt.Errorf(UNKNOWN_FUNCTION, name)
+}
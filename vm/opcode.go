@@ -0,0 +1,46 @@
+package vm
+
+import "fmt"
+
+type Opcode byte
+
+const (
+	// OP_CONST pushes the string constant at the operand.
+	OP_CONST Opcode = iota + 1
+	// OP_LOAD pushes the top level field of the input named by the constant
+	// at the operand.
+	OP_LOAD
+	// OP_GET replaces the top of the stack with its field or element named by
+	// the constant at the operand.
+	OP_GET
+	// OP_GET_DYNAMIC pops a key and replaces the top of the stack with its
+	// field or element named by the key.
+	OP_GET_DYNAMIC
+	// OP_JUMP_NOT_NULL jumps to the operand if the top of the stack is not
+	// null and pops it otherwise.
+	OP_JUMP_NOT_NULL
+	// OP_CALL replaces the top of the stack with the result of calling the
+	// function named by the constant at the operand.
+	OP_CALL
+)
+
+type definition struct {
+	name     string
+	operands int // width of the operand in bytes
+}
+
+var definitions = map[Opcode]definition{
+	OP_CONST:         {"CONST", 2},
+	OP_LOAD:          {"LOAD", 2},
+	OP_GET:           {"GET", 2},
+	OP_GET_DYNAMIC:   {"GET_DYNAMIC", 0},
+	OP_JUMP_NOT_NULL: {"JUMP_NOT_NULL", 2},
+	OP_CALL:          {"CALL", 2},
+}
+
+func (op Opcode) String() string {
+	if d, ok := definitions[op]; ok {
+		return d.name
+	}
+	return fmt.Sprintf("OP(%d)", byte(op))
+}
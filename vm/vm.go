@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+)
+
+// VM runs Bytecode with the semantics of eval.Evaluate. A VM reuses its stack
+// between runs and must not be used by several goroutines at once.
+type VM struct {
+	functions *functions.Registry
+	stack     []any
+}
+
+// New returns a VM calling the functions of fns. If fns is nil every function
+// is unknown, as with eval.Evaluate.
+func New(fns *functions.Registry) *VM {
+	return &VM{
+		functions: fns,
+		stack:     make([]any, 0, 16),
+	}
+}
+
+// Run executes bc against the JSON decoded data and returns the value left on
+// the stack.
+func (vm *VM) Run(bc *Bytecode, data any) (any, error) {
+	vm.stack = vm.stack[:0]
+	defer func() {
+		clear(vm.stack[:cap(vm.stack)])
+	}()
+
+	for pos := 0; pos < len(bc.Instructions); {
+		op, operand, next, err := bc.decode(pos)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case OP_CONST, OP_LOAD:
+			if operand >= len(bc.Constants) {
+				return nil, newInvalidOperandError(operand, op, pos)
+			}
+			if op == OP_CONST {
+				vm.stack = append(vm.stack, bc.Constants[operand])
+			} else {
+				vm.stack = append(vm.stack, eval.Access(data, bc.Constants[operand]))
+			}
+		case OP_GET:
+			if len(vm.stack) < 1 {
+				return nil, newStackUnderflowError(pos)
+			}
+			if operand >= len(bc.Constants) {
+				return nil, newInvalidOperandError(operand, op, pos)
+			}
+			top := len(vm.stack) - 1
+			vm.stack[top] = eval.Access(vm.stack[top], bc.Constants[operand])
+		case OP_GET_DYNAMIC:
+			if len(vm.stack) < 2 {
+				return nil, newStackUnderflowError(pos)
+			}
+			key := vm.stack[len(vm.stack)-1]
+			vm.stack = vm.stack[:len(vm.stack)-1]
+			top := len(vm.stack) - 1
+			vm.stack[top] = eval.Access(vm.stack[top], eval.ToKey(key))
+		case OP_JUMP_NOT_NULL:
+			if len(vm.stack) < 1 {
+				return nil, newStackUnderflowError(pos)
+			}
+			if operand <= pos || operand > len(bc.Instructions) {
+				return nil, newInvalidOperandError(operand, op, pos)
+			}
+			if vm.stack[len(vm.stack)-1] != nil {
+				next = operand
+			} else {
+				vm.stack = vm.stack[:len(vm.stack)-1]
+			}
+		case OP_CALL:
+			if len(vm.stack) < 1 {
+				return nil, newStackUnderflowError(pos)
+			}
+			if operand >= len(bc.Constants) {
+				return nil, newInvalidOperandError(operand, op, pos)
+			}
+			if vm.functions == nil {
+				return nil, newUnknownFunctionError(bc.Constants[operand])
+			}
+			top := len(vm.stack) - 1
+			v, err := vm.functions.Call(bc.Constants[operand], vm.stack[top])
+			if err != nil {
+				return nil, err
+			}
+			vm.stack[top] = v
+		}
+		pos = next
+	}
+
+	if len(vm.stack) != 1 {
+		return nil, newInvalidStackError(len(vm.stack))
+	}
+	return vm.stack[0], nil
+}
@@ -0,0 +1,129 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jorgepbrown/wildcard-tree/eval"
+	"github.com/jorgepbrown/wildcard-tree/functions"
+	"github.com/jorgepbrown/wildcard-tree/parser"
+	"github.com/jorgepbrown/wildcard-tree/tokenizer"
+)
+
+const testData = `{
+	"user": {"name": "ada", "nickname": null, "tags": ["a", "b"]},
+	"key": "name",
+	"index": 1,
+	"items": [{"id": 1}, {"id": 2}]
+}`
+
+func TestRunMatchesEvaluate(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(testData), &data); err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{
+		`{{"user"}}`,
+		"{{user.name}}",
+		"{{missing.deeply.nested}}",
+		"{{user.{{key}}}}",
+		"{{user.tags[{{index}}]}}",
+		"{{items.0.id}}",
+		`{{user.nickname ?? "anonymous"}}`,
+		"{{user.nickname ?? user.missing ?? user.name | toUpper}}",
+		"{{user.nickname ?? (user.missing ?? user.{{key}}) | toUpper}}",
+		"{{user.tags | length}}",
+		"{{user.tags | toUpper}}",
+		"{{user.name | toupper}}",
+	}
+
+	vm := New(functions.Default)
+	for i, input := range inputs {
+		t.Logf("vm-%d %s", i, input)
+		root := mustParse(input, t)
+		bc, err := Compile(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, expectedErr := eval.Evaluate(root, data, functions.Default)
+		actual, err := vm.Run(bc, data)
+		if (expectedErr == nil) != (err == nil) {
+			t.Fatalf("wrong error, expected=%v got=%v", expectedErr, err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("wrong result, expected=%v got=%v\n%s", expected, actual, bc)
+		}
+	}
+}
+
+func TestRunWithoutFunctions(t *testing.T) {
+	root := mustParse("{{ a | toUpper }}", t)
+	bc, err := Compile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expected := eval.Evaluate(root, nil, nil)
+	if _, err := New(nil).Run(bc, nil); err == nil || err.Error() != expected.Error() {
+		t.Fatalf("wrong error, expected=%v got=%v", expected, err)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	bc, err := Compile(mustParse(`{{ user.nickname ?? user[{{key}}] | toUpper }}`, t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `0000 LOAD 0 "user"
+0003 GET 1 "nickname"
+0006 JUMP_NOT_NULL 0016
+0009 LOAD 0 "user"
+0012 LOAD 2 "key"
+0015 GET_DYNAMIC
+0016 CALL 3 "toUpper"
+`
+	if bc.String() != expected {
+		t.Errorf("wrong disassembly, expected=\n%s\ngot=\n%s", expected, bc.String())
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	bc, err := Compile(mustParse(`{{ a.b ?? "c" | toUpper }}`, t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := bc.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Bytecode{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bc.Instructions, decoded.Instructions) || !reflect.DeepEqual(bc.Constants, decoded.Constants) {
+		t.Errorf("wrong round trip, expected=%v got=%v", bc, decoded)
+	}
+
+	invalid := [][]byte{
+		nil,
+		[]byte("WCBC\x02"),
+		data[:len(data)-1],
+		append([]byte("WCBC\x01\x00\x00\x00\x00\x00\x03"), byte(OP_CONST), 0, 0),
+		append([]byte("WCBC\x01\x00\x00\x00\x00\x00\x01"), 0xff),
+	}
+	for _, in := range invalid {
+		if err := (&Bytecode{}).UnmarshalBinary(in); err == nil {
+			t.Errorf("expected error decoding %q", in)
+		}
+	}
+}
+
+func mustParse(input string, t *testing.T) parser.Expression {
+	t.Helper()
+	ast, err := parser.New(tokenizer.New(input)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ast.Root
+}